@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/saguywalker/add2git-lfs/internal/gitcommand"
+)
+
+// runPush stores files in the folder like an upload from the browser, commits and pushes them
+func runPush(flags *flag.FlagSet, args []string) int {
+	opts := defaultOptions()
+	opts.repositoryFlags(flags)
+	opts.authorFlags(flags)
+	opts.storageFlags(flags)
+	opts.auditFlags(flags)
+	description := flags.String("description", "", "description of the files in their sidecars")
+	extract := flags.Bool("extract", false, "extract zip, tar and tar.gz archives into the folder")
+	tags := flags.String("tags", "", "comma-separated tags of the files in their sidecars")
+	uploader := flags.String("uploader", "", "uploader of the files in their sidecars (default: -user)")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx := context.Background()
+	logger := opts.logger()
+	config := opts.config(logger)
+	opts.setup(ctx, config, logger, false)
+	if config.Audit != nil {
+		defer config.Audit.Close()
+	}
+
+	actor := *uploader
+	if actor == "" {
+		actor = config.User
+	}
+	ctx = gitcommand.WithActor(ctx, gitcommand.Actor{User: actor})
+
+	sources, err := gitcommand.PathSources(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	values := map[string][]string{
+		"description": {*description},
+		"extract":     {strconv.FormatBool(*extract)},
+		"tags":        {*tags},
+		"uploader":    {*uploader},
+	}
+	result, err := config.StoreFiles(ctx, sources, values)
+	if dupErr, ok := err.(*gitcommand.DuplicateError); ok {
+		for _, d := range dupErr.Duplicates {
+			fmt.Fprintln(os.Stderr, d)
+		}
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, d := range result.Duplicates {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", d)
+	}
+	for _, q := range result.Quarantined {
+		fmt.Fprintf(os.Stderr, "warning: %s is quarantined in %s\n", q.File, q.Path)
+	}
+
+	job, err := config.RunPush(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "push job %s failed: %s\n", job.ID, err)
+		return 1
+	}
+
+	fmt.Printf("push job %s: %d files in commit %s pushed to %s/%s\n", job.ID, len(job.Files), job.Commit, config.Remote, config.Branch)
+	return 0
+}
+
+// runStatus prints the pending files, the unpushed commits and the last push jobs
+func runStatus(flags *flag.FlagSet, args []string) int {
+	opts := defaultOptions()
+	opts.repositoryFlags(flags)
+	asJSON := flags.Bool("json", false, "print the status as JSON")
+	jobs := flags.Int("jobs", 5, "number of push jobs to show")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	logger := opts.logger()
+	config := opts.config(logger)
+
+	status, err := config.Status(ctx, *jobs)
+	if err != nil {
+		fatal(logger, "cannot read the status", err)
+	}
+
+	if *asJSON {
+		return printJSON(status)
+	}
+
+	fmt.Printf("On branch %s, pushed to %s, folder %s\n", status.Branch, status.Remote, status.Folder)
+
+	fmt.Printf("\n%d pending files:\n", len(status.Pending))
+	for _, file := range status.Pending {
+		fmt.Printf("  %s (%d bytes)\n", file.Path, file.Size)
+	}
+
+	fmt.Printf("\n%d unpushed commits:\n", len(status.Unpushed))
+	for _, commit := range status.Unpushed {
+		fmt.Printf("  %s\n", commit)
+	}
+
+	fmt.Printf("\nlast push jobs:\n")
+	for _, job := range status.Jobs {
+		var result string
+		switch {
+		case job.Finished.IsZero():
+			result = "running or interrupted"
+		case job.Pushed:
+			result = fmt.Sprintf("pushed %s", job.Commit)
+		case job.Step != "":
+			result = fmt.Sprintf("failed at git %s", job.Step)
+		default:
+			result = job.Error
+		}
+		fmt.Printf("  %s  %s  %d files  %s\n", job.ID, job.Started.Local().Format("2006-01-02 15:04:05"), len(job.Files), result)
+	}
+
+	return 0
+}
+
+// runDoctor prints the preflight checks with fixes for the failed ones
+func runDoctor(flags *flag.FlagSet, args []string) int {
+	opts := defaultOptions()
+	opts.repositoryFlags(flags)
+	opts.authorFlags(flags)
+	opts.storageFlags(flags)
+	opts.freeSpaceFlag(flags)
+	flags.Parse(args)
+
+	ctx := context.Background()
+	logger := opts.logger()
+	config := opts.config(logger)
+	// Failed commands are reported by the checks, they are only logged when debugging
+	if opts.logLevel != "debug" {
+		config.Logger = nil
+	}
+
+	report := config.Doctor(ctx)
+
+	for _, check := range report.Checks {
+		status := " ok "
+		if !check.OK {
+			status = "FAIL"
+		}
+		var lines []string
+		for _, line := range strings.Split(check.Detail, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		fmt.Printf("[%s] %s: %s\n", status, check.Name, strings.Join(lines, "\n       "))
+		if check.Fix != "" {
+			fmt.Printf("       fix: %s\n", check.Fix)
+		}
+	}
+
+	if report.Status != "ok" {
+		return 1
+	}
+	return 0
+}
+
+// setting is a line of the config subcommand
+type setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// runConfig prints the settings serve and push would run with
+func runConfig(flags *flag.FlagSet, args []string) int {
+	opts := defaultOptions()
+	opts.repositoryFlags(flags)
+	opts.authorFlags(flags)
+	opts.storageFlags(flags)
+	opts.auditFlags(flags)
+	opts.freeSpaceFlag(flags)
+	asJSON := flags.Bool("json", false, "print the settings as JSON")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	logger := opts.logger()
+	config := opts.config(logger)
+	// Unset git config keys are shown as such, they are only logged when debugging
+	if opts.logLevel != "debug" {
+		config.Logger = nil
+	}
+
+	user := config.User
+	if user == "" {
+		user = config.GitConfig(ctx, "user.name")
+	}
+	email := config.Email
+	if email == "" {
+		email = config.GitConfig(ctx, "user.email")
+	}
+	var scanners []string
+	for _, scanner := range config.Scanners {
+		scanners = append(scanners, scanner.Name())
+	}
+	token := "not set"
+	if config.Token != "" {
+		token = "set"
+	}
+	lfs := "all files"
+	if config.LfsThreshold > 0 {
+		lfs = fmt.Sprintf("files from %d bytes", config.LfsThreshold)
+	}
+	auditLog := opts.auditLog
+	if auditLog == "" {
+		auditLog, _ = config.DefaultAuditLog(ctx)
+	}
+	stateDir, _ := config.StateDir(ctx)
+
+	settings := []setting{
+		{"branch", config.Branch},
+		{"remote", config.Remote},
+		{"remote.url", config.GitConfig(ctx, fmt.Sprintf("remote.%s.url", config.Remote))},
+		{"folder", config.UploadsDir},
+		{"user.name", user},
+		{"user.email", email},
+		{"token", token},
+		{"lfs", lfs},
+		{"naming", config.Naming},
+		{"metadata", config.Metadata},
+		{"zip", fmt.Sprint(config.ZipPassword != "")},
+		{"scanners", strings.Join(scanners, ",")},
+		{"scan-action", config.ScanAction},
+		{"duplicates", config.Duplicates},
+		{"max-file-size", fmt.Sprint(config.Policy.MaxFileSize)},
+		{"max-push-size", fmt.Sprint(config.Policy.MaxPushSize)},
+		{"max-files", fmt.Sprint(config.Policy.MaxFiles)},
+		{"max-archive-entries", fmt.Sprint(config.Policy.MaxArchiveEntries)},
+		{"max-archive-size", fmt.Sprint(config.Policy.MaxArchiveSize)},
+		{"allow-ext", strings.Join(config.Policy.AllowedExtensions, ",")},
+		{"block-ext", strings.Join(config.Policy.BlockedExtensions, ",")},
+		{"allow-type", strings.Join(config.Policy.AllowedTypes, ",")},
+		{"block-type", strings.Join(config.Policy.BlockedTypes, ",")},
+		{"audit-log", auditLog},
+		{"min-free-space", fmt.Sprint(config.MinFreeSpace)},
+		{"state-dir", stateDir},
+	}
+
+	if *asJSON {
+		return printJSON(settings)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, s := range settings {
+		fmt.Fprintf(w, "%s\t%s\n", s.Key, s.Value)
+	}
+	w.Flush()
+	return 0
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,208 @@
+package gitcommand
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Archive formats extracted on upload
+const (
+	ArchiveZip   = "zip"
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar.gz"
+)
+
+// archiveFormat returns the format of an archive from its name, or "" for another file
+func archiveFormat(filename string) string {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveZip
+	case strings.HasSuffix(name, ".tar"):
+		return ArchiveTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveTarGz
+	}
+	return ""
+}
+
+// extractRequested tells whether the extract field of an upload form is set
+func extractRequested(values map[string][]string) bool {
+	value := strings.ToLower(formValue(values, "extract"))
+	if value == "on" {
+		return true
+	}
+	extract, _ := strconv.ParseBool(value)
+	return extract
+}
+
+// extraction stages the entries of an archive within the limits of the Policy
+type extraction struct {
+	config  *Config
+	archive string
+	entries int
+	size    int64
+	uploads []upload
+}
+
+// extractArchives replaces the staged archives of uploads by their staged entries when the form asks for it
+// On error, the entries staged so far are removed and uploads are returned unchanged
+func (config *Config) extractArchives(uploads []upload, values map[string][]string) ([]upload, error) {
+	if !extractRequested(values) {
+		return uploads, nil
+	}
+
+	var extracted []upload
+	for _, file := range uploads {
+		format := archiveFormat(file.Filename)
+		if format == "" {
+			extracted = append(extracted, file)
+			continue
+		}
+
+		x := &extraction{config: config, archive: file.Filename}
+		if err := x.extract(file.Temp, format); err != nil {
+			removeStaged(x.uploads)
+			removeStaged(extracted)
+			return uploads, err
+		}
+		extracted = append(extracted, x.uploads...)
+	}
+
+	// The archives are not stored, only their entries
+	for i := range uploads {
+		if archiveFormat(uploads[i].Filename) != "" {
+			removeStaged(uploads[i : i+1])
+		}
+	}
+	return extracted, nil
+}
+
+// extract stages the regular files of the archive at path, links and other special entries are skipped
+func (x *extraction) extract(path, format string) error {
+	if format == ArchiveZip {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return x.invalid(err)
+		}
+		defer reader.Close()
+
+		for _, entry := range reader.File {
+			if !entry.Mode().IsRegular() {
+				continue
+			}
+			src, err := entry.Open()
+			if err != nil {
+				return x.invalid(err)
+			}
+			err = x.add(entry.Name, src)
+			src.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var src io.Reader = file
+	if format == ArchiveTarGz {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return x.invalid(err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	reader := tar.NewReader(src)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return x.invalid(err)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		if err := x.add(header.Name, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// add stages an entry, keeping its directories under UploadsDir when files keep their original name
+func (x *extraction) add(name string, r io.Reader) error {
+	policy := x.config.Policy
+
+	name, err := entryName(name)
+	if err != nil {
+		return &PolicyError{http.StatusUnprocessableEntity, x.archive, err.Error()}
+	}
+
+	x.entries++
+	if policy.MaxArchiveEntries > 0 && x.entries > policy.MaxArchiveEntries {
+		return &PolicyError{http.StatusRequestEntityTooLarge, x.archive, fmt.Sprintf("archive has more than %d entries", policy.MaxArchiveEntries)}
+	}
+
+	if policy.MaxArchiveSize > 0 {
+		r = io.LimitReader(r, policy.MaxArchiveSize-x.size+1)
+	}
+
+	file, err := x.config.stage(name, r)
+	if err != nil {
+		return err
+	}
+	x.uploads = append(x.uploads, file)
+
+	x.size += file.Size
+	if policy.MaxArchiveSize > 0 && x.size > policy.MaxArchiveSize {
+		return &PolicyError{http.StatusRequestEntityTooLarge, x.archive, fmt.Sprintf("archive extracts to more than %d bytes", policy.MaxArchiveSize)}
+	}
+
+	staged := &x.uploads[len(x.uploads)-1]
+	staged.Archive = x.archive
+	if !x.config.contentAddressed() {
+		staged.Path = filepath.Join(x.config.UploadsDir, filepath.FromSlash(name))
+	}
+	return nil
+}
+
+// invalid returns the error of an archive which cannot be read
+func (x *extraction) invalid(err error) error {
+	return &PolicyError{http.StatusUnprocessableEntity, x.archive, fmt.Sprintf("cannot read archive: %s", err.Error())}
+}
+
+// entryName cleans the name of an archive entry into a relative slash-separated path
+// Names escaping the folder, absolute or with a volume, and names going through a .git directory are refused
+func entryName(name string) (string, error) {
+	slashed := strings.Replace(name, "\\", "/", -1)
+	clean := path.Clean(slashed)
+
+	if slashed == "" || path.IsAbs(slashed) || strings.Contains(clean, ":") || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("entry %q is outside of the folder", name)
+	}
+	for _, part := range strings.Split(clean, "/") {
+		if strings.EqualFold(part, ".git") {
+			return "", fmt.Errorf("entry %q is in a .git directory", name)
+		}
+	}
+
+	return clean, nil
+}
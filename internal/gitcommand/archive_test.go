@@ -0,0 +1,159 @@
+package gitcommand
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveFormat(t *testing.T) {
+	cases := map[string]string{
+		"samples.zip":    ArchiveZip,
+		"samples.TAR":    ArchiveTar,
+		"samples.tar.gz": ArchiveTarGz,
+		"samples.tgz":    ArchiveTarGz,
+		"samples.7z":     "",
+		"sample.exe":     "",
+	}
+
+	for name, format := range cases {
+		if got := archiveFormat(name); got != format {
+			t.Fatalf("%s: got %q, want %q", name, got, format)
+		}
+	}
+}
+
+func TestEntryName(t *testing.T) {
+	cases := []struct {
+		name  string
+		clean string
+		valid bool
+	}{
+		{"hello.txt", "hello.txt", true},
+		{"dir/./sub/../hello.txt", "dir/hello.txt", true},
+		{`dir\hello.txt`, "dir/hello.txt", true},
+		{"../hello.txt", "", false},
+		{"dir/../../hello.txt", "", false},
+		{`..\hello.txt`, "", false},
+		{"/etc/passwd", "", false},
+		{`C:\hello.txt`, "", false},
+		{".git/hooks/pre-commit", "", false},
+		{"dir/.GIT/config", "", false},
+		{"", "", false},
+	}
+
+	for _, c := range cases {
+		clean, err := entryName(c.name)
+		if (err == nil) != c.valid || clean != c.clean {
+			t.Fatalf("%q: got %q, %v", c.name, clean, err)
+		}
+	}
+}
+
+// writeArchive stages an archive of entries in dir
+func writeArchive(t *testing.T, config *Config, name string, entries map[string]string) upload {
+	var buf bytes.Buffer
+
+	switch archiveFormat(name) {
+	case ArchiveZip:
+		w := zip.NewWriter(&buf)
+		for entry, content := range entries {
+			f, err := w.Create(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write([]byte(content))
+		}
+		w.Close()
+	default:
+		gz := gzip.NewWriter(&buf)
+		w := tar.NewWriter(gz)
+		w.WriteHeader(&tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink})
+		for entry, content := range entries {
+			w.WriteHeader(&tar.Header{Name: entry, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+			w.Write([]byte(content))
+		}
+		w.Close()
+		gz.Close()
+	}
+
+	file, err := config.stage(name, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestExtractArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entries := map[string]string{"hello.txt": "hello", "dir/sub/world.txt": "world"}
+	extract := map[string][]string{"extract": {"on"}}
+
+	cases := []struct {
+		archive string
+		entries map[string]string
+		policy  Policy
+		values  map[string][]string
+		paths   []string
+		status  int
+	}{
+		{"samples.zip", entries, Policy{}, extract, []string{"dir/sub/world.txt", "hello.txt"}, 0},
+		{"samples.tar.gz", entries, Policy{}, extract, []string{"dir/sub/world.txt", "hello.txt"}, 0},
+		{"samples.zip", entries, Policy{}, nil, []string{"samples.zip"}, 0},
+		{"samples.zip", entries, Policy{MaxArchiveEntries: 1}, extract, nil, http.StatusRequestEntityTooLarge},
+		{"samples.tar.gz", entries, Policy{MaxArchiveSize: 9}, extract, nil, http.StatusRequestEntityTooLarge},
+		{"samples.zip", map[string]string{"../evil.txt": "evil"}, Policy{}, extract, nil, http.StatusUnprocessableEntity},
+		{"samples.tar.gz", map[string]string{"/evil.txt": "evil"}, Policy{}, extract, nil, http.StatusUnprocessableEntity},
+	}
+
+	for _, c := range cases {
+		config := &Config{UploadsDir: filepath.Join(dir, "uploads"), Policy: c.policy}
+		archive := writeArchive(t, config, c.archive, c.entries)
+
+		uploads, err := config.extractArchives([]upload{archive}, c.values)
+		if c.status != 0 {
+			if policyErr, ok := err.(*PolicyError); !ok || policyErr.Status != c.status {
+				t.Fatalf("%s: got %v, want status %d", c.archive, err, c.status)
+			}
+			if len(uploads) != 1 || uploads[0].Temp != archive.Temp {
+				t.Fatalf("%s: got %v, want the archive", c.archive, uploads)
+			}
+			removeStaged(uploads)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		paths := make(map[string]bool)
+		for _, file := range uploads {
+			rel, _ := filepath.Rel(config.UploadsDir, file.Path)
+			paths[filepath.ToSlash(rel)] = true
+			if content, err := ioutil.ReadFile(file.Temp); err != nil || (file.Archive != "" && string(content) != c.entries[file.Filename]) {
+				t.Fatalf("%s: got %q, %v", file.Filename, content, err)
+			}
+		}
+		for _, path := range c.paths {
+			if !paths[path] || len(paths) != len(c.paths) {
+				t.Fatalf("%s: got %v, want %v", c.archive, paths, c.paths)
+			}
+		}
+		removeStaged(uploads)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "uploads", tempPattern))
+	if len(matches) != 0 {
+		t.Fatal(matches)
+	}
+}
@@ -0,0 +1,113 @@
+package gitcommand
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// attributesFile is the gitattributes file at the root of the worktree
+	attributesFile = ".gitattributes"
+	// lfsAttributes are the attributes written by git lfs track
+	lfsAttributes = "filter=lfs diff=lfs merge=lfs -text"
+	// blobAttributes take back the attributes of an LFS pattern, left unspecified a file is stored as a regular
+	// git object and diffed and merged as text when it is text
+	blobAttributes = "!filter !diff !merge !text"
+)
+
+// RouteFiles writes a .gitattributes entry for every file in UploadsDir, but the temporary ones
+// Files of at least LfsThreshold bytes go through LFS, smaller ones are stored as regular git objects
+func (config *Config) RouteFiles() error {
+	if config.LfsThreshold <= 0 {
+		return nil
+	}
+
+	attrs := make(map[string]string)
+	err := filepath.Walk(config.UploadsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, tempSuffix) {
+			return nil
+		}
+		if isMetadata(path) {
+			attrs[attributePattern(path)] = blobAttributes
+		} else if info.Mode().IsRegular() {
+			attrs[attributePattern(path)] = routeAttributes(info.Size(), config.LfsThreshold)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return updateAttributes(attrs)
+}
+
+// updateAttributes sets the attributes of the given patterns in .gitattributes
+func updateAttributes(attrs map[string]string) error {
+	content, err := ioutil.ReadFile(attributesFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return ioutil.WriteFile(attributesFile, mergeAttributes(content, attrs), 0644)
+}
+
+// isLfs tells whether a file of UploadsDir goes through LFS
+func (config *Config) isLfs(path string, size int64) bool {
+	return !isMetadata(path) && (config.LfsThreshold <= 0 || size >= config.LfsThreshold)
+}
+
+// routeAttributes returns the attributes for a file of the given size
+func routeAttributes(size, threshold int64) string {
+	if size >= threshold {
+		return lfsAttributes
+	}
+	return blobAttributes
+}
+
+// attributePatternEscaper escapes the glob characters of a path, and the spaces which would end the pattern
+var attributePatternEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "*", `\*`, "?", `\?`, " ", "[[:space:]]")
+
+// attributePattern returns a gitattributes pattern matching exactly the given path
+func attributePattern(path string) string {
+	return attributePatternEscaper.Replace(filepath.ToSlash(filepath.Clean(path)))
+}
+
+// mergeAttributes replaces the lines of a gitattributes content whose pattern is in attrs, keeping the others as they are
+// New entries are appended in sorted order so the file stays stable between runs
+func mergeAttributes(content []byte, attrs map[string]string) []byte {
+	var out bytes.Buffer
+
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if line == "" {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			if _, ok := attrs[fields[0]]; ok {
+				continue
+			}
+		}
+		out.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+
+	patterns := make([]string, 0, len(attrs))
+	for pattern := range attrs {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		out.WriteString(pattern + " " + attrs[pattern] + "\n")
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,106 @@
+package gitcommand
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+var routeCases = []struct {
+	size      int64
+	threshold int64
+	out       string
+}{
+	{0, 1024, blobAttributes},
+	{1023, 1024, blobAttributes},
+	{1024, 1024, lfsAttributes},
+	{1 << 30, 1024, lfsAttributes},
+}
+
+func TestRouteAttributes(t *testing.T) {
+	for _, c := range routeCases {
+		if out := routeAttributes(c.size, c.threshold); out != c.out {
+			t.Fatalf("routeAttributes(%d, %d) = %q, want %q", c.size, c.threshold, out, c.out)
+		}
+	}
+}
+
+func TestAttributePattern(t *testing.T) {
+	cases := map[string]string{
+		"./sample-files/my sample.bin": "sample-files/my[[:space:]]sample.bin",
+		"sample-files/[draft] *.bin":   `sample-files/\[draft][[:space:]]\*.bin`,
+		`sample-files/what?\.bin`:      `sample-files/what\?\\.bin`,
+	}
+
+	for path, want := range cases {
+		if out := attributePattern(path); out != want {
+			t.Fatalf("%s: got %s, want %s", path, out, want)
+		}
+	}
+}
+
+func TestMergeAttributes(t *testing.T) {
+	in := "# samples\nsample-files/* filter=lfs diff=lfs merge=lfs -text\n\n  *.txt   text\nsample-files/a.bin -filter -diff -merge"
+	attrs := map[string]string{
+		"sample-files/b.txt": blobAttributes,
+		"sample-files/a.bin": lfsAttributes,
+	}
+	want := "# samples\nsample-files/* filter=lfs diff=lfs merge=lfs -text\n\n  *.txt   text\n" +
+		"sample-files/a.bin " + lfsAttributes + "\n" +
+		"sample-files/b.txt " + blobAttributes + "\n"
+
+	if out := string(mergeAttributes([]byte(in), attrs)); out != want {
+		t.Fatalf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestRouteFiles(t *testing.T) {
+	defer testRepo(t)()
+
+	files := map[string]string{
+		"sample-files/small.txt":                "small",
+		"sample-files/large.bin":                strings.Repeat("x", 2048),
+		"sample-files/small.txt.meta.json":      "{}",
+		"sample-files/.upload.123" + tempSuffix: "staged",
+		"sample-files/.probe.456" + tempSuffix:  "",
+	}
+	os.Mkdir("sample-files", os.ModePerm)
+	lfsLine := "sample-files/** " + lfsAttributes + "\n"
+	if err := ioutil.WriteFile(attributesFile, []byte(lfsLine), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range files {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &Config{UploadsDir: "sample-files", LfsThreshold: 1024}
+	if err := config.RouteFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(attributesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lfsLine +
+		"sample-files/large.bin filter=lfs diff=lfs merge=lfs -text\n" +
+		"sample-files/small.txt !filter !diff !merge !text\n" +
+		"sample-files/small.txt.meta.json !filter !diff !merge !text\n"
+	if string(content) != want {
+		t.Fatalf("got\n%s\nwant\n%s", content, want)
+	}
+
+	// Small files and sidecars are left to git as any other file, not taken for binaries
+	for _, path := range []string{"sample-files/small.txt", "sample-files/small.txt.meta.json"} {
+		out := testGit(t, "check-attr", "filter", "diff", "merge", "text", "--", path)
+		if strings.Count(out, ": unspecified") != 4 {
+			t.Fatalf("got\n%s", out)
+		}
+	}
+	if out := testGit(t, "check-attr", "filter", "--", "sample-files/large.bin"); !strings.HasSuffix(out, "filter: lfs") {
+		t.Fatalf("got %s", out)
+	}
+}
@@ -0,0 +1,350 @@
+package gitcommand
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditUpload = "upload"
+	AuditCommit = "commit"
+	AuditPush   = "push"
+	AuditDelete = "delete"
+	AuditReset  = "reset"
+)
+
+// Statuses of audited actions
+const (
+	AuditOK      = "ok"
+	AuditRefused = "refused"
+	AuditFailed  = "failed"
+)
+
+// auditFile is the default audit log in the state directory
+const auditFile = "audit.jsonl"
+
+// AuditEvent is a line of the audit log
+type AuditEvent struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Status string    `json:"status"`
+	IP     string    `json:"ip,omitempty"`
+	User   string    `json:"user,omitempty"`
+	Files  []string  `json:"files,omitempty"`
+	Commit string    `json:"commit,omitempty"`
+	Job    string    `json:"job,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// AuditFilter selects events of the audit log, empty fields match everything
+type AuditFilter struct {
+	Action string
+	Status string
+	User   string
+	IP     string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// AuditLog is an append-only log of JSON lines, rotated when it reaches MaxSize
+type AuditLog struct {
+	Path    string
+	MaxSize int64
+	Keep    int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Actor is who made a request
+type Actor struct {
+	IP   string
+	User string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// DefaultAuditLog returns the path of the audit log in the state directory
+func (config *Config) DefaultAuditLog(ctx context.Context) (string, error) {
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, auditFile), nil
+}
+
+// OpenAuditLog opens the audit log at path for appending, keeping keep rotated files of maxSize bytes
+func OpenAuditLog(path string, maxSize int64, keep int) (*AuditLog, error) {
+	l := &AuditLog{Path: path, MaxSize: maxSize, Keep: keep}
+	return l, l.open()
+}
+
+func (l *AuditLog) open() error {
+	file, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// Record appends an event to the log
+func (l *AuditLog) Record(event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.MaxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.MaxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// rotate moves the log to Path.1, shifting older files up to Path.Keep
+func (l *AuditLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	os.Remove(l.rotated(l.Keep))
+	for i := l.Keep; i > 0; i-- {
+		if err := os.Rename(l.rotated(i-1), l.rotated(i)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return l.open()
+}
+
+// rotated returns the path of the i-th rotated file, 0 being the current one
+func (l *AuditLog) rotated(i int) string {
+	if i == 0 {
+		return l.Path
+	}
+	return l.Path + "." + strconv.Itoa(i)
+}
+
+// Close closes the log
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Query returns the events matching filter, oldest first, across rotated files
+// With a Limit, only the most recent events are returned
+func (l *AuditLog) Query(filter AuditFilter) ([]AuditEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events := []AuditEvent{}
+	for i := l.Keep; i >= 0; i-- {
+		file, err := os.Open(l.rotated(i))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var event AuditEvent
+			if json.Unmarshal(scanner.Bytes(), &event) == nil && filter.match(event) {
+				events = append(events, event)
+			}
+		}
+		file.Close()
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[len(events)-filter.Limit:]
+	}
+
+	return events, nil
+}
+
+func (filter AuditFilter) match(event AuditEvent) bool {
+	return (filter.Action == "" || filter.Action == event.Action) &&
+		(filter.Status == "" || filter.Status == event.Status) &&
+		(filter.User == "" || strings.EqualFold(filter.User, event.User)) &&
+		(filter.IP == "" || filter.IP == event.IP) &&
+		(filter.Since.IsZero() || !event.Time.Before(filter.Since)) &&
+		(filter.Until.IsZero() || event.Time.Before(filter.Until))
+}
+
+// audit records an event made by the actor of ctx, failures to write are logged
+func (config *Config) audit(ctx context.Context, event AuditEvent) {
+	if config.Audit == nil {
+		return
+	}
+
+	actor := actorFrom(ctx)
+	event.Time = time.Now().UTC()
+	event.IP = actor.IP
+	event.User = actor.User
+
+	if err := config.Audit.Record(event); err != nil {
+		config.loggerFor(ctx).Error("audit", "action", event.Action, "error", err)
+	}
+}
+
+// ParseTrustedProxies parses the addresses and CIDR ranges of authenticating proxies
+func ParseTrustedProxies(list []string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, item := range list {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipnet, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q", item)
+		}
+		proxies = append(proxies, ipnet)
+	}
+	return proxies, nil
+}
+
+// Identify is an echo middleware putting the client IP and user in the request context
+// The server checks no credentials, the user and the client IP forwarded in the X-Forwarded-User and X-Forwarded-For
+// headers are only taken from the TrustedProxies, which authenticate the users, the peer address is the IP otherwise
+func (config *Config) Identify(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			host = req.RemoteAddr
+		}
+		actor := Actor{IP: host}
+		if config.trustedProxy(host) {
+			actor.IP = forwardedFor(req, host)
+			actor.User = req.Header.Get("X-Forwarded-User")
+		}
+
+		c.SetRequest(req.WithContext(WithActor(req.Context(), actor)))
+		return next(c)
+	}
+}
+
+// forwardedFor returns the client IP a proxy at host forwarded, the last one of X-Forwarded-For
+// as the earlier ones come from the client
+func forwardedFor(req *http.Request, host string) string {
+	if forwarded := SplitList(req.Header.Get("X-Forwarded-For")); len(forwarded) > 0 {
+		return forwarded[len(forwarded)-1]
+	}
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return host
+}
+
+// trustedProxy tells whether host is the address of one of the TrustedProxies
+func (config *Config) trustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range config.TrustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleAudit queries the audit log with the action, status, user, ip, since, until and limit query parameters
+func (config *Config) HandleAudit(c echo.Context) error {
+	if config.Audit == nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "audit log is disabled"})
+	}
+
+	filter := AuditFilter{
+		Action: c.QueryParam("action"),
+		Status: c.QueryParam("status"),
+		User:   c.QueryParam("user"),
+		IP:     c.QueryParam("ip"),
+	}
+
+	var err error
+	if since := c.QueryParam("since"); since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "since must be an RFC 3339 date"})
+		}
+	}
+	if until := c.QueryParam("until"); until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "until must be an RFC 3339 date"})
+		}
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "limit must be a number"})
+		}
+	}
+
+	events, err := config.Audit.Query(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
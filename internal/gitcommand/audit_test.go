@@ -0,0 +1,109 @@
+package gitcommand
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+func TestAuditLogRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, err := OpenAuditLog(filepath.Join(dir, "audit.jsonl"), 200, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	start := time.Date(2019, 9, 7, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		event := AuditEvent{Time: start.Add(time.Duration(i) * time.Minute), Action: AuditUpload, Status: AuditOK, User: "alice"}
+		if i%2 == 1 {
+			event.Action = AuditPush
+		}
+		if err := l.Record(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(l.Path + ".2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(l.Path + ".3"); !os.IsNotExist(err) {
+		t.Fatal("only 2 rotated files should be kept")
+	}
+
+	all, err := l.Query(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 || len(all) >= 10 || !all[len(all)-1].Time.Equal(start.Add(9*time.Minute)) {
+		t.Fatal(all)
+	}
+
+	pushes, err := l.Query(AuditFilter{Action: AuditPush, User: "ALICE", Since: start.Add(6 * time.Minute), Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pushes) != 1 || !pushes[0].Time.Equal(start.Add(9*time.Minute)) {
+		t.Fatal(pushes)
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTrustedProxies([]string{"proxy.local"}); err == nil {
+		t.Fatal("a host name should be refused")
+	}
+	config := &Config{TrustedProxies: proxies}
+
+	cases := []struct {
+		remote    string
+		forwarded string
+		basicAuth bool
+		actor     Actor
+	}{
+		{"10.0.0.1:4321", "203.0.113.9, 198.51.100.7", false, Actor{"198.51.100.7", "alice"}},
+		{"192.168.1.2:4321", "", false, Actor{"192.168.1.2", "alice"}},
+		{"10.0.0.2:4321", "198.51.100.7", false, Actor{"10.0.0.2", ""}},
+		{"[::1]:4321", "", true, Actor{"::1", ""}},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = c.remote
+		req.Header.Set("X-Forwarded-User", "alice")
+		if c.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if c.basicAuth {
+			req.SetBasicAuth("mallory", "unchecked")
+		}
+
+		var actor Actor
+		e := echo.New()
+		handler := config.Identify(func(ctx echo.Context) error {
+			actor = actorFrom(ctx.Request().Context())
+			return nil
+		})
+		if err := handler(e.NewContext(req, httptest.NewRecorder())); err != nil {
+			t.Fatal(err)
+		}
+		if actor != c.actor {
+			t.Fatalf("%s: got %+v, want %+v", c.remote, actor, c.actor)
+		}
+	}
+}
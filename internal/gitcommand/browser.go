@@ -0,0 +1,330 @@
+package gitcommand
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// maxPointerSize is the size above which a blob cannot be an LFS pointer
+const maxPointerSize = 1024
+
+// File is a file of UploadsDir on a ref
+type File struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Oid      string    `json:"oid,omitempty"`
+	Commit   string    `json:"commit,omitempty"`
+	Author   string    `json:"author,omitempty"`
+	Date     time.Time `json:"date"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// FileFilter selects files in a listing, empty fields match everything
+type FileFilter struct {
+	Query  string
+	Tag    string
+	Type   string
+	Author string
+}
+
+// treeEntry is a blob listed by git ls-tree
+type treeEntry struct {
+	Object string
+	Size   int64
+	Path   string
+}
+
+// HandleListFiles lists the files of UploadsDir on the branch, or on the ref query parameter
+func (config *Config) HandleListFiles(c echo.Context) error {
+	ref := c.QueryParam("ref")
+	if ref == "" {
+		ref = config.Branch
+	}
+
+	files, err := config.ListFiles(c.Request().Context(), ref)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+
+	filter := FileFilter{
+		Query:  c.QueryParam("q"),
+		Tag:    c.QueryParam("tag"),
+		Type:   c.QueryParam("type"),
+		Author: c.QueryParam("author"),
+	}
+
+	return c.JSON(http.StatusOK, filterFiles(files, filter))
+}
+
+// ListFiles returns the files of UploadsDir on a ref with their LFS OID, last commit and sidecar metadata
+func (config *Config) ListFiles(ctx context.Context, ref string) ([]File, error) {
+	if !validRef(ref) {
+		return nil, errInvalidRef(ref)
+	}
+
+	entries, err := config.lsTree(ctx, ref, config.UploadsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sidecars are read whatever their size, other files only when they may be pointers
+	var objects []string
+	for _, entry := range entries {
+		if isMetadata(entry.Path) || entry.Size <= maxPointerSize {
+			objects = append(objects, entry.Object)
+		}
+	}
+	blobs, err := config.catBlobs(ctx, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := config.lastCommits(ctx, ref, config.UploadsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sidecars := make(map[string]*Metadata)
+	for _, entry := range entries {
+		for format, suffix := range metadataSuffixes {
+			if strings.HasSuffix(entry.Path, suffix) {
+				if meta, err := decodeMetadata(blobs[entry.Object], format); err == nil {
+					sidecars[strings.TrimSuffix(entry.Path, suffix)] = meta
+				}
+			}
+		}
+	}
+
+	var files []File
+	for _, entry := range entries {
+		if isMetadata(entry.Path) {
+			continue
+		}
+
+		file := File{Path: entry.Path, Size: entry.Size, Metadata: sidecars[entry.Path]}
+		if oid, size, ok := parsePointer(blobs[entry.Object]); ok {
+			file.Oid = oid
+			file.Size = size
+		}
+		if commit, ok := commits[entry.Path]; ok {
+			file.Commit = commit.Commit
+			file.Author = commit.Author
+			file.Date = commit.Date
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// filterFiles returns the files matching filter
+func filterFiles(files []File, filter FileFilter) []File {
+	matches := []File{}
+
+	for _, file := range files {
+		meta := file.Metadata
+		if meta == nil {
+			meta = &Metadata{}
+		}
+
+		if filter.Query != "" && !containsFold(filter.Query, file.Path, meta.Filename, meta.Description, meta.Sha256, file.Oid, strings.Join(meta.Tags, " ")) {
+			continue
+		}
+		if filter.Tag != "" && !containsTag(meta.Tags, filter.Tag) {
+			continue
+		}
+		if filter.Type != "" && !matchType(filter.Type, meta.MimeType) {
+			continue
+		}
+		if filter.Author != "" && !containsFold(filter.Author, file.Author, meta.Uploader) {
+			continue
+		}
+		matches = append(matches, file)
+	}
+
+	return matches
+}
+
+// containsFold tells whether one of values contains substr, ignoring case
+func containsFold(substr string, values ...string) bool {
+	substr = strings.ToLower(substr)
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsTag tells whether tags contains tag, ignoring case
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// validRef refuses refs that git would take for an option
+func validRef(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "-") && !strings.ContainsAny(ref, " \t\n\x00")
+}
+
+func errInvalidRef(ref string) error {
+	return fmt.Errorf("invalid ref %q", ref)
+}
+
+// lsTree lists the blobs under dir on a ref
+func (config *Config) lsTree(ctx context.Context, ref, dir string) ([]treeEntry, error) {
+	out, err := config.git(ctx, "ls-tree", "-r", "-l", "-z", ref, "--", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLsTree(out), nil
+}
+
+// parseLsTree parses the "<mode> <type> <object> <size>\t<path>" entries of git ls-tree -r -l -z
+func parseLsTree(out []byte) []treeEntry {
+	var entries []treeEntry
+
+	for _, line := range bytes.Split(out, []byte{0}) {
+		tab := bytes.IndexByte(line, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(string(line[:tab]))
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		entries = append(entries, treeEntry{fields[2], size, string(line[tab+1:])})
+	}
+
+	return entries
+}
+
+// catBlobs returns the content of blobs by object name
+func (config *Config) catBlobs(ctx context.Context, objects []string) (map[string][]byte, error) {
+	blobs := make(map[string][]byte)
+	if len(objects) == 0 {
+		return blobs, nil
+	}
+
+	out, err := config.commandInput(ctx, strings.NewReader(strings.Join(objects, "\n")+"\n"), "git", "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(out))
+	for {
+		header, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return blobs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, err
+		}
+		blobs[fields[0]] = content[:size]
+	}
+}
+
+// parsePointer returns the OID and size of an LFS pointer
+func parsePointer(content []byte) (string, int64, bool) {
+	if !bytes.HasPrefix(content, []byte("version https://git-lfs.github.com/spec/v1\n")) {
+		return "", 0, false
+	}
+
+	var oid string
+	var size int64 = -1
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "oid sha256:") {
+			oid = strings.TrimPrefix(line, "oid sha256:")
+		} else if strings.HasPrefix(line, "size ") {
+			size, _ = strconv.ParseInt(strings.TrimPrefix(line, "size "), 10, 64)
+		}
+	}
+
+	return oid, size, validOid(oid) && size >= 0
+}
+
+// validOid tells whether oid is a SHA-256 as LFS writes it, 64 lowercase hexadecimal characters
+// OIDs come from the repository and end up in paths of the LFS store, anything else is refused
+func validOid(oid string) bool {
+	if len(oid) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range oid {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// lastCommits returns the last commit touching each path under dir on a ref
+func (config *Config) lastCommits(ctx context.Context, ref, dir string) (map[string]Commit, error) {
+	commits, err := config.History(ctx, ref, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[string]Commit)
+	for _, commit := range commits {
+		for _, name := range commit.Files {
+			if _, ok := last[name]; !ok {
+				last[name] = commit
+			}
+		}
+	}
+
+	return last, nil
+}
+
+// decodeMetadata parses a sidecar in the given format
+func decodeMetadata(content []byte, format string) (*Metadata, error) {
+	if content == nil {
+		return nil, errors.New("no content")
+	}
+
+	meta := &Metadata{}
+	var err error
+	if format == MetadataYAML {
+		err = yaml.Unmarshal(content, meta)
+	} else {
+		err = json.Unmarshal(content, meta)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
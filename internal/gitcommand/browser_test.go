@@ -0,0 +1,96 @@
+package gitcommand
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLsTree(t *testing.T) {
+	out := []byte("100644 blob 0123456789abcdef0123456789abcdef01234567     132\tsample-files/hello.txt\x00" +
+		"040000 tree 89abcdef0123456789abcdef0123456789abcdef       -\tsample-files/2c\x00" +
+		"100644 blob 89abcdef0123456789abcdef0123456789abcdef      20\tsample-files/my sample.bin\x00")
+	want := []treeEntry{
+		{"0123456789abcdef0123456789abcdef01234567", 132, "sample-files/hello.txt"},
+		{"89abcdef0123456789abcdef0123456789abcdef", 20, "sample-files/my sample.bin"},
+	}
+
+	if entries := parseLsTree(out); !reflect.DeepEqual(entries, want) {
+		t.Fatal(entries)
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + helloOid + "\nsize 5\n"
+
+	oid, size, ok := parsePointer([]byte(pointer))
+	if !ok || oid != helloOid || size != 5 {
+		t.Fatal(oid, size, ok)
+	}
+
+	for _, content := range []string{
+		"hello",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:../../../../etc/passwd\nsize 5\n",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + strings.ToUpper(helloOid) + "\nsize 5\n",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + helloOid[:63] + "\nsize 5\n",
+	} {
+		if _, _, ok := parsePointer([]byte(content)); ok {
+			t.Fatalf("%q is not a pointer", content)
+		}
+	}
+}
+
+func TestFilterFiles(t *testing.T) {
+	files := []File{
+		{Path: "sample-files/a.exe", Author: "Alice", Metadata: &Metadata{MimeType: "application/octet-stream", Tags: []string{"Emotet"}}},
+		{Path: "sample-files/b.png", Author: "Bob", Metadata: &Metadata{MimeType: "image/png", Description: "screenshot"}},
+		{Path: "sample-files/c.txt", Author: "Bob"},
+	}
+
+	cases := []struct {
+		filter FileFilter
+		paths  []string
+	}{
+		{FileFilter{}, []string{"sample-files/a.exe", "sample-files/b.png", "sample-files/c.txt"}},
+		{FileFilter{Query: "SCREEN"}, []string{"sample-files/b.png"}},
+		{FileFilter{Tag: "emotet"}, []string{"sample-files/a.exe"}},
+		{FileFilter{Type: "image/*"}, []string{"sample-files/b.png"}},
+		{FileFilter{Author: "bob", Query: ".txt"}, []string{"sample-files/c.txt"}},
+	}
+
+	for _, c := range cases {
+		var paths []string
+		for _, file := range filterFiles(files, c.filter) {
+			paths = append(paths, file.Path)
+		}
+		if !reflect.DeepEqual(paths, c.paths) {
+			t.Fatalf("%+v: %v", c.filter, paths)
+		}
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	defer testRepo(t)()
+
+	// A long description makes a sidecar larger than a pointer
+	description := strings.Repeat("a long description ", 100)
+	testCommit(t, "sample-files/hello.txt", "hello")
+	testCommit(t, "sample-files/hello.txt.meta.json", `{"filename": "hello.txt", "description": "`+description+`"}`)
+	testCommit(t, "sample-files/large.bin", strings.Repeat("x", 2*maxPointerSize))
+
+	config := &Config{Branch: "master", UploadsDir: "sample-files"}
+	files, err := config.ListFiles(context.Background(), "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Path != "sample-files/hello.txt" || files[1].Path != "sample-files/large.bin" {
+		t.Fatalf("got %+v", files)
+	}
+	if files[0].Metadata == nil || files[0].Metadata.Description != description {
+		t.Fatalf("got %+v", files[0].Metadata)
+	}
+	if files[1].Size != 2*maxPointerSize || files[1].Oid != "" {
+		t.Fatalf("got %+v", files[1])
+	}
+}
@@ -0,0 +1,199 @@
+package gitcommand
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the request it serves
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID of ctx, if any
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// logger returns the Logger of config, or one discarding everything
+func (config *Config) logger() *slog.Logger {
+	if config.Logger == nil {
+		return slog.New(slog.NewTextHandler(ioutil.Discard, nil))
+	}
+	return config.Logger
+}
+
+// loggerFor returns the logger of config with the request ID of ctx
+func (config *Config) loggerFor(ctx context.Context) *slog.Logger {
+	if id := RequestIDFrom(ctx); id != "" {
+		return config.logger().With("request_id", id)
+	}
+	return config.logger()
+}
+
+// command runs a command and returns its standard output, logging its duration and exit code
+// ctx only carries the request ID, a cancelled request must not kill a git command halfway
+func (config *Config) command(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return config.commandInput(ctx, nil, name, args...)
+}
+
+// commandInput runs a command reading stdin, see command
+func (config *Config) commandInput(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = stdin
+	return config.run(ctx, cmd)
+}
+
+// gitNoPrompt runs a git command failing instead of asking for credentials on the terminal, see command
+func (config *Config) gitNoPrompt(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	return config.run(ctx, cmd)
+}
+
+// run runs a prepared command, see command
+func (config *Config) run(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	detach(cmd)
+
+	atomic.AddInt32(&config.running, 1)
+	defer atomic.AddInt32(&config.running, -1)
+
+	start := time.Now()
+	// A command writing to its own Stdout returns no output
+	var out []byte
+	var err error
+	if cmd.Stdout != nil {
+		err = cmd.Run()
+	} else {
+		out, err = cmd.Output()
+	}
+
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	config.loggerFor(ctx).Log(ctx, level, "command",
+		"cmd", config.redact(strings.Join(cmd.Args, " ")),
+		"duration", time.Since(start),
+		"exit_code", exitCode,
+		"stderr", config.redact(strings.TrimSpace(stderr.String())))
+
+	if err != nil {
+		return nil, fmt.Errorf("%s\n%w", string(out)+stderr.String(), err)
+	}
+
+	return out, nil
+}
+
+// git runs a git command, see command
+func (config *Config) git(ctx context.Context, args ...string) ([]byte, error) {
+	return config.command(ctx, "git", args...)
+}
+
+// GitConfig returns the value of a git config key, empty when it is not set
+func (config *Config) GitConfig(ctx context.Context, key string) string {
+	out, err := config.git(ctx, "config", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// redact hides the token in a logged string
+func (config *Config) redact(s string) string {
+	if config.Token == "" {
+		return s
+	}
+	return strings.Replace(s, config.Token, "***", -1)
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// RequestID is an echo middleware putting the X-Request-ID header, or a new ID, in the request context and the response
+func RequestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Request().Header.Get(echo.HeaderXRequestID)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		req := c.Request()
+		c.SetRequest(req.WithContext(WithRequestID(req.Context(), id)))
+		return next(c)
+	}
+}
+
+// LogRequests is an echo middleware logging each request with its status and duration
+func (config *Config) LogRequests(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+
+		req := c.Request()
+		level := slog.LevelInfo
+		if c.Response().Status >= 500 {
+			level = slog.LevelError
+		}
+		config.loggerFor(req.Context()).Log(req.Context(), level, "request",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"status", c.Response().Status,
+			"bytes", c.Response().Size,
+			"duration", time.Since(start),
+			"ip", c.RealIP())
+
+		return nil
+	}
+}
+
+// NewLogger returns a logger writing to w in the text or json format from the given level
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
@@ -0,0 +1,42 @@
+package gitcommand
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCommandLogging(t *testing.T) {
+	var logs bytes.Buffer
+	logger, err := NewLogger(&logs, "json", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{Token: "s3cr3t", Logger: logger}
+	ctx := WithRequestID(context.Background(), "abcd")
+
+	if _, err := config.git(ctx, "rev-parse", "--verify", "s3cr3t"); err == nil {
+		t.Fatal("s3cr3t is not a revision")
+	}
+
+	line := logs.String()
+	for _, want := range []string{`"request_id":"abcd"`, `"cmd":"git rev-parse --verify ***"`, `"exit_code":128`} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("%s not in %s", want, line)
+		}
+	}
+	if strings.Contains(line, "s3cr3t") {
+		t.Fatal("token is logged")
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	if _, err := NewLogger(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Fatal("xml is not a log format")
+	}
+	if _, err := NewLogger(&bytes.Buffer{}, "text", "verbose"); err == nil {
+		t.Fatal("verbose is not a log level")
+	}
+}
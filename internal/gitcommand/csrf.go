@@ -0,0 +1,167 @@
+package gitcommand
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+)
+
+const (
+	// csrfCookie holds the CSRF token of a browser, the pages read it to send it back
+	csrfCookie = "_csrf"
+	// csrfField is the form field carrying the token in the forms posted without script
+	csrfField = "csrf"
+)
+
+// CheckOrigin is an echo middleware refusing requests for another host than AllowedHosts,
+// such as a DNS rebinding attack, and mutating requests from another origin than the server or AllowedOrigins
+// Requests without Origin and Referer do not come from a browser and are not checked against the origin
+func (config *Config) CheckOrigin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		if !config.allowedHost(req.Host) {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "host " + req.Host + " is not allowed"})
+		}
+
+		if safeMethod(req.Method) {
+			return next(c)
+		}
+
+		origin := browserOrigin(req)
+		if origin != "" && !sameOrigin(origin, req) && !config.allowedOrigin(origin) {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "origin " + origin + " is not allowed"})
+		}
+
+		return next(c)
+	}
+}
+
+// CSRF is an echo middleware protecting mutating requests of the pages with a double submitted token
+// The token is kept in a cookie, the pages send it back in the X-CSRF-Token header or the csrf form field
+// Requests from AllowedOrigins and from clients other than browsers are not checked, see CheckOrigin
+func (config *Config) CSRF(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		cookie, err := req.Cookie(csrfCookie)
+		if err != nil || len(cookie.Value) != 64 {
+			cookie = &http.Cookie{Name: csrfCookie, Value: newCSRFToken(), Path: "/", Secure: c.IsTLS(), SameSite: http.SameSiteStrictMode}
+			c.SetCookie(cookie)
+			if safeMethod(req.Method) {
+				return next(c)
+			}
+		}
+
+		origin := browserOrigin(req)
+		if safeMethod(req.Method) || (origin == "" && err != nil) || (origin != "" && config.allowedOrigin(origin)) {
+			return next(c)
+		}
+
+		token := req.Header.Get(echo.HeaderXCSRFToken)
+		if token == "" && strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationForm) {
+			token = req.PostFormValue(csrfField)
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "invalid CSRF token"})
+		}
+
+		return next(c)
+	}
+}
+
+// CORS returns an echo middleware letting the pages of AllowedOrigins call the API
+// Without AllowedOrigins, browsers keep other origins from reading the responses
+func (config *Config) CORS() echo.MiddlewareFunc {
+	if len(config.AllowedOrigins) == 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:  config.AllowedOrigins,
+		AllowMethods:  []string{http.MethodGet, http.MethodHead, http.MethodPost},
+		AllowHeaders:  []string{echo.HeaderContentType, echo.HeaderXRequestID, echo.HeaderAuthorization, HeaderContentSha256},
+		ExposeHeaders: []string{echo.HeaderXRequestID, echo.HeaderContentDisposition},
+	})
+}
+
+// allowedHost tells whether the Host header of a request is one of AllowedHosts, any host is allowed without them
+func (config *Config) allowedHost(host string) bool {
+	if len(config.AllowedHosts) == 0 {
+		return true
+	}
+
+	name := hostname(host)
+	for _, allowed := range config.AllowedHosts {
+		if allowed == "*" || strings.EqualFold(allowed, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedOrigin tells whether origin is one of AllowedOrigins
+func (config *Config) allowedOrigin(origin string) bool {
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// browserOrigin returns the Origin header of a request, or the origin of its Referer
+func browserOrigin(req *http.Request) string {
+	if origin := req.Header.Get(echo.HeaderOrigin); origin != "" {
+		return origin
+	}
+
+	referer, err := url.Parse(req.Referer())
+	if err != nil || referer.Host == "" {
+		return ""
+	}
+	return referer.Scheme + "://" + referer.Host
+}
+
+// sameOrigin tells whether origin is the origin of the server handling req
+func sameOrigin(origin string, req *http.Request) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return u.Scheme == scheme && strings.EqualFold(u.Host, req.Host)
+}
+
+// hostname returns the host of a Host header without its port
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return strings.Trim(host, "[]")
+}
+
+// safeMethod tells whether a method does not change anything
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// newCSRFToken returns a random CSRF token
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
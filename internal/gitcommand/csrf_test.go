@@ -0,0 +1,73 @@
+package gitcommand
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+const testCSRFToken = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+var originCases = []struct {
+	name    string
+	method  string
+	host    string
+	headers map[string]string
+	body    string
+	status  int
+}{
+	{"page", http.MethodGet, "127.0.0.1:12358", nil, "", http.StatusOK},
+	{"dns rebinding", http.MethodGet, "evil.example:12358", nil, "", http.StatusForbidden},
+	{"cli", http.MethodPost, "127.0.0.1:12358", nil, "", http.StatusOK},
+	{"cross-site form", http.MethodPost, "127.0.0.1:12358", map[string]string{"Origin": "http://evil.example"}, "", http.StatusForbidden},
+	{"cross-site referer", http.MethodPost, "127.0.0.1:12358", map[string]string{"Referer": "http://evil.example/page"}, "", http.StatusForbidden},
+	{"missing token", http.MethodPost, "127.0.0.1:12358", map[string]string{"Origin": "http://127.0.0.1:12358"}, "", http.StatusForbidden},
+	{"wrong token", http.MethodPost, "127.0.0.1:12358", map[string]string{"Origin": "http://127.0.0.1:12358", "Cookie": csrfCookie + "=" + testCSRFToken, "X-CSRF-Token": "wrong"}, "", http.StatusForbidden},
+	{"header token", http.MethodPost, "127.0.0.1:12358", map[string]string{"Origin": "http://127.0.0.1:12358", "Cookie": csrfCookie + "=" + testCSRFToken, "X-CSRF-Token": testCSRFToken}, "", http.StatusOK},
+	{"form token", http.MethodPost, "localhost:12358", map[string]string{"Origin": "http://localhost:12358", "Cookie": csrfCookie + "=" + testCSRFToken, "Content-Type": "application/x-www-form-urlencoded"}, "csrf=" + testCSRFToken, http.StatusOK},
+	{"allowed origin", http.MethodPost, "127.0.0.1:12358", map[string]string{"Origin": "https://dashboard.example"}, "", http.StatusOK},
+}
+
+func TestCheckOriginAndCSRF(t *testing.T) {
+	config := &Config{AllowedHosts: LocalHosts("127.0.0.1"), AllowedOrigins: []string{"https://dashboard.example"}}
+	handler := config.CheckOrigin(config.CSRF(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}))
+
+	e := echo.New()
+	for _, c := range originCases {
+		req := httptest.NewRequest(c.method, "/pushfiles", strings.NewReader(c.body))
+		req.Host = c.host
+		for key, value := range c.headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != c.status {
+			t.Fatalf("%s: got %d, want %d: %s", c.name, rec.Code, c.status, rec.Body.String())
+		}
+	}
+}
+
+func TestCSRFCookie(t *testing.T) {
+	config := &Config{}
+	handler := config.CSRF(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	if err := handler(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)); err != nil {
+		t.Fatal(err)
+	}
+
+	cookie := rec.Header().Get("Set-Cookie")
+	if !strings.HasPrefix(cookie, csrfCookie+"=") || !strings.Contains(cookie, "SameSite=Strict") {
+		t.Fatal(cookie)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+// +build !linux,!darwin,!freebsd,!dragonfly,!windows
+
+package gitcommand
+
+// freeSpace cannot measure the disk on this platform, its check is skipped
+func freeSpace(path string) (int64, error) {
+	return 0, errFreeSpaceUnsupported
+}
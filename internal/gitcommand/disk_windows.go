@@ -0,0 +1,19 @@
+package gitcommand
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// freeSpace returns the bytes available to the user on the disk of path
+func freeSpace(path string) (int64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
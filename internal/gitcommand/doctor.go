@@ -0,0 +1,190 @@
+package gitcommand
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Doctor runs the preflight checks of the environment, from the installation of git to the credentials
+func (config *Config) Doctor(ctx context.Context) Report {
+	return newReport(ctx,
+		config.CheckGit,
+		config.CheckLfs,
+		config.CheckRepository,
+		config.CheckLfsHooks,
+		config.CheckRemote,
+		config.CheckTokenURL,
+		config.CheckCredentials,
+		config.CheckBranch,
+		config.CheckAttributes,
+		config.CheckUploadsWritable,
+		config.CheckFreeSpace,
+	)
+}
+
+// CheckLfsHooks checks that git lfs install added its pre-push hook
+func (config *Config) CheckLfsHooks(ctx context.Context) Check {
+	check := Check{Name: "lfs hooks", Fix: "run git lfs install in the repository"}
+
+	out, err := config.git(ctx, "rev-parse", "--git-path", "hooks/pre-push")
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+
+	hook := strings.TrimSpace(string(out))
+	content, err := ioutil.ReadFile(hook)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+
+	check.OK = strings.Contains(string(content), "git lfs pre-push") || strings.Contains(string(content), "git-lfs pre-push")
+	check.Detail = hook
+	return check
+}
+
+// CheckTokenURL checks that the URL of Remote can take the token
+func (config *Config) CheckTokenURL(ctx context.Context) Check {
+	check := Check{Name: "token url", OK: true, Detail: "no token"}
+	if config.Token == "" {
+		return check
+	}
+
+	url, err := config.TokenURL(ctx)
+	if err != nil {
+		check.OK = false
+		check.Detail = err.Error()
+		check.Fix = fmt.Sprintf("set an https://host/user/repository or git@host:user/repository URL with git remote set-url %s <url>", config.Remote)
+		return check
+	}
+
+	check.Detail = config.redact(url)
+	return check
+}
+
+// CheckCredentials checks that Remote can be reached with the configured credentials
+func (config *Config) CheckCredentials(ctx context.Context) Check {
+	check := Check{Name: "credentials"}
+
+	remote := config.Remote
+	if config.Token != "" {
+		url, err := config.TokenURL(ctx)
+		if err != nil {
+			check.Detail = err.Error()
+			check.Fix = "fix the token url first"
+			return check
+		}
+		remote = url
+	}
+
+	if _, err := config.gitNoPrompt(ctx, "ls-remote", "--heads", remote); err != nil {
+		check.Detail = config.redact(strings.TrimSpace(err.Error()))
+		if config.Token != "" {
+			check.Fix = "check that the token is valid and allowed to write to the repository"
+		} else {
+			check.Fix = fmt.Sprintf("configure a credential helper or an SSH key for %s, or use -token", config.Remote)
+		}
+		return check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s is reachable", config.Remote)
+	return check
+}
+
+// CheckBranch reports whether Branch exists locally and on Remote, missing branches are created
+func (config *Config) CheckBranch(ctx context.Context) Check {
+	check := Check{Name: "branch", OK: true}
+
+	local := "exists locally"
+	if _, err := config.git(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+config.Branch); err != nil {
+		local = "will be created on start"
+	}
+
+	remote := fmt.Sprintf("exists on %s", config.Remote)
+	out, err := config.gitNoPrompt(ctx, "ls-remote", "--heads", config.Remote, config.Branch)
+	if err != nil {
+		remote = fmt.Sprintf("cannot be looked up on %s", config.Remote)
+	} else if len(strings.TrimSpace(string(out))) == 0 {
+		remote = "will be created on first push"
+	}
+
+	check.Detail = fmt.Sprintf("%s %s, %s", config.Branch, local, remote)
+	return check
+}
+
+// CheckAttributes checks that .gitattributes sends files of UploadsDir through LFS and keeps sidecars out of it
+func (config *Config) CheckAttributes(ctx context.Context) Check {
+	check := Check{Name: ".gitattributes"}
+
+	if config.LfsThreshold > 0 {
+		check.OK = true
+		check.Detail = fmt.Sprintf("files are routed by size from %d bytes on each push", config.LfsThreshold)
+		return check
+	}
+
+	probe := filepath.ToSlash(config.UploadPath("probe", strings.Repeat("0", 64)))
+	want := map[string]string{"filter": "lfs", "diff": "lfs", "merge": "lfs", "text": "unset"}
+
+	got, err := config.checkAttr(ctx, probe, "filter", "diff", "merge", "text")
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+
+	var wrong []string
+	for _, attr := range []string{"filter", "diff", "merge", "text"} {
+		if got[attr] != want[attr] {
+			wrong = append(wrong, fmt.Sprintf("%s is %s instead of %s", attr, got[attr], want[attr]))
+		}
+	}
+
+	if format := config.metadataFormat(); format != MetadataNone {
+		sidecar, err := config.checkAttr(ctx, probe+metadataSuffixes[format], "filter")
+		if err != nil {
+			check.Detail = err.Error()
+			return check
+		}
+		if sidecar["filter"] == "lfs" {
+			wrong = append(wrong, "sidecars go through LFS")
+		}
+	}
+
+	if len(wrong) > 0 {
+		check.Detail = strings.Join(wrong, ", ")
+		check.Fix = fmt.Sprintf("run git lfs track \"%s\", or start add2git-lfs to update .gitattributes, then commit it", config.trackPattern())
+		return check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s goes through LFS", config.trackPattern())
+	return check
+}
+
+// checkAttr returns the values of attributes for path
+func (config *Config) checkAttr(ctx context.Context, path string, attrs ...string) (map[string]string, error) {
+	out, err := config.git(ctx, append(append([]string{"check-attr"}, attrs...), "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCheckAttr(string(out)), nil
+}
+
+// parseCheckAttr parses the "<path>: <attribute>: <value>" lines of git check-attr
+func parseCheckAttr(out string) map[string]string {
+	values := make(map[string]string)
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ": ")
+		if len(fields) >= 3 {
+			values[fields[len(fields)-2]] = fields[len(fields)-1]
+		}
+	}
+
+	return values
+}
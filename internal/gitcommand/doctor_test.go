@@ -0,0 +1,15 @@
+package gitcommand
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCheckAttr(t *testing.T) {
+	out := "sample-files/probe: filter: lfs\nsample-files/probe: diff: lfs\nsample-files/probe: merge: lfs\nsample-files/probe: text: unset\n"
+	want := map[string]string{"filter": "lfs", "diff": "lfs", "merge": "lfs", "text": "unset"}
+
+	if values := parseCheckAttr(out); !reflect.DeepEqual(values, want) {
+		t.Fatal(values)
+	}
+}
@@ -0,0 +1,187 @@
+package gitcommand
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Content is the content of a file on a ref
+type Content struct {
+	io.ReadSeeker
+	io.Closer
+	// Etag identifies the content, it is the LFS OID or the blob name
+	Etag string
+}
+
+// HandleDownload streams the content of a file of UploadsDir on the branch, or on the ref query parameter
+// LFS pointers are resolved to their object, Range requests are supported
+func (config *Config) HandleDownload(c echo.Context) error {
+	ref := c.QueryParam("ref")
+	if ref == "" {
+		ref = config.Branch
+	}
+
+	name := c.Param("*")
+	if c.Request().URL.RawPath != "" {
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+	}
+
+	name, ok := config.repositoryPath(name)
+	if !ok {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "file is not in " + config.UploadsDir})
+	}
+
+	content, err := config.OpenFile(c.Request().Context(), ref, name)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	defer content.Close()
+
+	res := c.Response()
+	res.Header().Set("Etag", strconv.Quote(content.Etag))
+	res.Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}))
+	http.ServeContent(res, c.Request(), path.Base(name), time.Time{}, content)
+
+	return nil
+}
+
+// repositoryPath returns the slash-separated path of name in the repository if it is in UploadsDir
+func (config *Config) repositoryPath(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	dir := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(config.UploadsDir)), "/")
+
+	return name, strings.HasPrefix(name, dir+"/")
+}
+
+// OpenFile opens the content of a file on a ref
+// An LFS object missing from the local store is fetched from Remote first
+func (config *Config) OpenFile(ctx context.Context, ref, name string) (*Content, error) {
+	if !validRef(ref) {
+		return nil, errInvalidRef(ref)
+	}
+
+	out, err := config.git(ctx, "rev-parse", "--verify", "--quiet", ref+":"+name)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found on %s", name, ref)
+	}
+	object := strings.TrimSpace(string(out))
+
+	out, err = config.git(ctx, "cat-file", "-s", object)
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > maxPointerSize {
+		return config.spoolBlob(ctx, object)
+	}
+
+	blob, err := config.git(ctx, "cat-file", "blob", object)
+	if err != nil {
+		return nil, err
+	}
+	if oid, _, ok := parsePointer(blob); ok {
+		return config.openLfsObject(ctx, ref, name, oid)
+	}
+
+	return &Content{bytes.NewReader(blob), noopCloser{}, object}, nil
+}
+
+// spoolBlob streams a blob too large to be a pointer into a temporary file, so it is served with Range requests
+// without being held in memory, the file is removed when the content is closed
+func (config *Config) spoolBlob(ctx context.Context, object string) (*Content, error) {
+	file, err := ioutil.TempFile("", "add2git-lfs-download-*")
+	if err != nil {
+		return nil, err
+	}
+	spooled := &spooledFile{file}
+
+	cmd := exec.Command("git", "cat-file", "blob", object)
+	cmd.Stdout = file
+	if _, err := config.run(ctx, cmd); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+
+	return &Content{file, spooled, object}, nil
+}
+
+// openLfsObject opens an object of the local LFS store, fetching it when missing
+func (config *Config) openLfsObject(ctx context.Context, ref, name, oid string) (*Content, error) {
+	objectPath, err := config.lfsObjectPath(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(objectPath); os.IsNotExist(err) {
+		if _, err := config.git(ctx, "lfs", "fetch", "--include="+name, config.Remote, ref); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(objectPath)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil {
+		config.Metrics.lfsBytes("download", info.Size())
+	}
+
+	return &Content{file, file, oid}, nil
+}
+
+// lfsObjectPath returns where git lfs stores an object
+func (config *Config) lfsObjectPath(ctx context.Context, oid string) (string, error) {
+	if !validOid(oid) {
+		return "", fmt.Errorf("invalid LFS OID %q", oid)
+	}
+
+	out, err := config.git(ctx, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(strings.TrimSpace(string(out)), "lfs", "objects", oid[:2], oid[2:4], oid), nil
+}
+
+// spooledFile is a temporary file removed once closed
+type spooledFile struct {
+	*os.File
+}
+
+func (f *spooledFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// noopCloser closes nothing, for contents read in memory
+type noopCloser struct{}
+
+func (noopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,83 @@
+package gitcommand
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var repositoryPathCases = []struct {
+	in  string
+	out string
+	ok  bool
+}{
+	{"sample-files/hello.txt", "sample-files/hello.txt", true},
+	{"/sample-files/2c/f2/" + helloOid, "sample-files/2c/f2/" + helloOid, true},
+	{"sample-files/../main.go", "main.go", false},
+	{"../../etc/passwd", "etc/passwd", false},
+	{"sample-files", "sample-files", false},
+	{"sample-filesystem/a", "sample-filesystem/a", false},
+}
+
+func TestRepositoryPath(t *testing.T) {
+	config := &Config{UploadsDir: "./sample-files"}
+
+	for _, c := range repositoryPathCases {
+		if out, ok := config.repositoryPath(c.in); out != c.out || ok != c.ok {
+			t.Fatalf("repositoryPath(%q) = %q, %t", c.in, out, ok)
+		}
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	defer testRepo(t)()
+
+	pointer := func(oid string) string {
+		return "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 5\n"
+	}
+	evil := pointer("../../../../../../../../etc/passwd")
+	large := strings.Repeat("not a pointer ", 200)
+
+	testCommit(t, "sample-files/hello.txt", pointer(helloOid))
+	testCommit(t, "sample-files/evil.txt", evil)
+	testCommit(t, "sample-files/large.txt", large)
+
+	object := filepath.Join(".git", "lfs", "objects", helloOid[:2], helloOid[2:4], helloOid)
+	os.MkdirAll(filepath.Dir(object), os.ModePerm)
+	if err := ioutil.WriteFile(object, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		content string
+	}{
+		{"sample-files/hello.txt", "hello"},
+		{"sample-files/evil.txt", evil},
+		{"sample-files/large.txt", large},
+	}
+
+	config := &Config{Remote: "origin"}
+	for _, c := range cases {
+		content, err := config.OpenFile(context.Background(), "master", c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(content)
+		content.Close()
+		if err != nil || string(data) != c.content {
+			t.Fatalf("%s: got %q, %v", c.name, data, err)
+		}
+
+		if spooled, ok := content.Closer.(*spooledFile); ok {
+			if _, err := os.Stat(spooled.Name()); !os.IsNotExist(err) {
+				t.Fatalf("%s: %s should be removed", c.name, spooled.Name())
+			}
+		} else if c.content == large {
+			t.Fatalf("%s should be spooled", c.name)
+		}
+	}
+}
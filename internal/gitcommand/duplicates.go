@@ -0,0 +1,91 @@
+package gitcommand
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Duplicate modes for uploaded files whose content is already in the repository
+const (
+	DuplicatesAllow  = "allow"
+	DuplicatesWarn   = "warn"
+	DuplicatesRefuse = "refuse"
+)
+
+// Duplicate is an uploaded file whose content is already tracked by LFS
+type Duplicate struct {
+	File     string `json:"file"`
+	Existing string `json:"existing"`
+	Oid      string `json:"oid"`
+}
+
+func (d Duplicate) String() string {
+	return fmt.Sprintf("%s is already in the repository as %s", d.File, d.Existing)
+}
+
+// DuplicateError refuses an upload with files already tracked by LFS
+type DuplicateError struct {
+	Duplicates []Duplicate
+}
+
+func (e *DuplicateError) Error() string {
+	return e.Duplicates[0].String()
+}
+
+// ValidDuplicates tells whether mode is a known duplicate mode
+func ValidDuplicates(mode string) bool {
+	return mode == DuplicatesAllow || mode == DuplicatesWarn || mode == DuplicatesRefuse
+}
+
+// findDuplicates returns the uploads whose content is already tracked by LFS on the branch
+func (config *Config) findDuplicates(ctx context.Context, uploads []upload) ([]Duplicate, error) {
+	if config.Duplicates == "" || config.Duplicates == DuplicatesAllow {
+		return nil, nil
+	}
+
+	objects, err := config.LfsObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []Duplicate
+	for _, file := range uploads {
+		// Uploading the same content to the same path changes nothing
+		target := filepath.ToSlash(file.Path)
+		for _, path := range objects[file.Oid] {
+			if path != target {
+				duplicates = append(duplicates, Duplicate{file.Filename, path, file.Oid})
+				break
+			}
+		}
+	}
+
+	return duplicates, nil
+}
+
+// LfsObjects returns the paths of the LFS files of the checked out branch by OID
+func (config *Config) LfsObjects(ctx context.Context) (map[string][]string, error) {
+	out, err := config.git(ctx, "lfs", "ls-files", "--long")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLsFiles(string(out)), nil
+}
+
+// parseLsFiles parses the "<oid> <*|-> <path>" lines of git lfs ls-files --long
+func parseLsFiles(out string) map[string][]string {
+	objects := make(map[string][]string)
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(strings.TrimRight(line, "\r"), " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		objects[fields[0]] = append(objects[fields[0]], fields[2])
+	}
+
+	return objects
+}
@@ -0,0 +1,20 @@
+package gitcommand
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLsFiles(t *testing.T) {
+	out := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824 * sample-files/hello.txt\n" +
+		"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824 - sample-files/my copy.txt\r\n" +
+		"486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7 * sample-files/world.txt\n"
+	want := map[string][]string{
+		"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824": {"sample-files/hello.txt", "sample-files/my copy.txt"},
+		"486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7": {"sample-files/world.txt"},
+	}
+
+	if objects := parseLsFiles(out); !reflect.DeepEqual(objects, want) {
+		t.Fatal(objects)
+	}
+}
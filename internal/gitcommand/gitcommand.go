@@ -1,14 +1,16 @@
 package gitcommand
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"io"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo"
 )
@@ -22,6 +24,72 @@ type Config struct {
 	Token      string
 	UploadsDir string
 	User       string
+
+	// LfsThreshold is the size in bytes from which a file goes through LFS
+	// Zero tracks every file in UploadsDir with LFS
+	LfsThreshold int64
+
+	// Policy limits the files accepted by HandleUpload
+	Policy Policy
+
+	// Duplicates tells HandleUpload to allow, warn about or refuse files already tracked by LFS
+	Duplicates string
+
+	// Naming tells HandleUpload to store files by their original name, their SHA-256 or a sharded SHA-256
+	Naming string
+
+	// Metadata is the format of the sidecar written next to each uploaded file, if any
+	Metadata string
+
+	// ZipPassword wraps each uploaded file into a zip encrypted with it, when it is not empty
+	ZipPassword string
+
+	// Scanners check each uploaded file, ScanAction tells whether detected files are blocked, tagged or quarantined
+	Scanners    []Scanner
+	ScanAction  string
+	ScanTimeout time.Duration
+
+	// Audit records uploads, commits and pushes when it is not nil
+	Audit *AuditLog
+
+	// Logger logs git commands and requests, nothing is logged when it is nil
+	Logger *slog.Logger
+
+	// Metrics records uploads and push jobs when it is not nil
+	Metrics *Metrics
+
+	// MinFreeSpace is the free space in bytes under which the server is not ready
+	MinFreeSpace int64
+
+	// AllowedHosts are the names and addresses accepted in the Host header, any when empty
+	AllowedHosts []string
+
+	// AllowedOrigins are the origins of other sites allowed to call the API
+	AllowedOrigins []string
+
+	// TrustedProxies are the authenticating proxies whose forwarded user and client IP are recorded
+	TrustedProxies []*net.IPNet
+
+	// pushMu runs push jobs one at a time, uploads store their files under its read lock
+	// so a push never commits a file without its sidecar
+	pushMu sync.RWMutex
+
+	// uploadsMu guards the uploads journal
+	uploadsMu sync.Mutex
+
+	// running counts the commands running, stopping is set once the server shuts down
+	running  int32
+	stopping int32
+}
+
+// HeaderContentSha256 carries the comma-separated SHA-256 of the uploaded files, in their order
+const HeaderContentSha256 = "X-Content-Sha256"
+
+// UploadResult is the response of a successful upload
+type UploadResult struct {
+	Message     string        `json:"message"`
+	Duplicates  []Duplicate   `json:"duplicates,omitempty"`
+	Quarantined []Quarantined `json:"quarantined,omitempty"`
 }
 
 // NewConfig returns a new Config
@@ -39,71 +107,103 @@ func NewConfig(branch, email, os, remote, token, uploadsDir, user string) *Confi
 
 // InitLfs runs necessary commands before open a web application
 // Including checkout to a specified branch, initialized git lfs, track a specified directory and add it to a worktree
-func (config *Config) InitLfs() error {
+func (config *Config) InitLfs(ctx context.Context) error {
 	var cmd string
 	var args []string
 
 	if config.OS == "windows" {
 		cmd = "cmd"
-		args = []string{"/C", fmt.Sprintf("git checkout -f && (git checkout %s || git checkout -b %s) && git lfs install && git lfs track \"%s/*\" && git add .gitattributes", config.Branch, config.Branch, config.UploadsDir)}
+		setup := fmt.Sprintf("git checkout -f && (git checkout %s || git checkout -b %s) && git lfs install", config.Branch, config.Branch)
+		if config.LfsThreshold <= 0 {
+			setup += fmt.Sprintf(" && git lfs track \"%s\" && git add .gitattributes", config.trackPattern())
+		}
+		args = []string{"/C", setup}
 	} else {
 		cmd = "git"
 
-		exec.Command(cmd, "checkout -f").Output()
+		config.command(ctx, cmd, "checkout -f")
 
-		err := exec.Command(cmd, "checkout", config.Branch).Run()
-		if err != nil {
-			exec.Command(cmd, "checkout", "-b", config.Branch).Run()
+		if _, err := config.command(ctx, cmd, "checkout", config.Branch); err != nil {
+			config.command(ctx, cmd, "checkout", "-b", config.Branch)
 		}
 
-		out, err := exec.Command("git-lfs", "install").Output()
-		if err != nil {
-			return fmt.Errorf("%s\n%s", string(out), err.Error())
+		if _, err := config.command(ctx, "git-lfs", "install"); err != nil {
+			return err
 		}
 
-		out, err = exec.Command("git-lfs", "track", fmt.Sprintf("%s/*", config.UploadsDir)).Output()
-		if err != nil {
-			return fmt.Errorf("%s\n%s", string(out), err.Error())
+		// Files are routed one by one by GitAddFile
+		if config.LfsThreshold > 0 {
+			return config.trackMetadata()
+		}
+
+		if _, err := config.command(ctx, "git-lfs", "track", config.trackPattern()); err != nil {
+			return err
 		}
 
 		args = []string{"add", ".gitattributes"}
 	}
 
-	out, err := exec.Command(cmd, args...).Output()
-	if err != nil {
-		return fmt.Errorf("%s\n%s", string(out), err.Error())
+	if _, err := config.command(ctx, cmd, args...); err != nil {
+		return err
 	}
 
-	return nil
+	return config.trackMetadata()
+}
+
+// trackMetadata keeps sidecar files out of LFS so they stay readable from the repository
+// The patterns must come after the LFS one in .gitattributes, they are added on each start
+func (config *Config) trackMetadata() error {
+	if config.metadataFormat() == MetadataNone {
+		return nil
+	}
+
+	attrs := make(map[string]string)
+	for _, suffix := range metadataSuffixes {
+		attrs["*"+suffix] = blobAttributes
+	}
+	return updateAttributes(attrs)
 }
 
 // GitAddFile adds files in a specified directory to a worktree
-func (config *Config) GitAddFile() error {
+// .gitattributes is added along with the files, with a LfsThreshold it is updated first
+func (config *Config) GitAddFile(ctx context.Context) error {
 	var cmd string
 	var args []string
 
+	if err := config.RouteFiles(); err != nil {
+		return err
+	}
+	paths := []string{attributesFile, config.UploadsDir}
+
 	if config.OS == "windows" {
 		cmd = "cmd"
-		args = []string{"/C", fmt.Sprintf("git add %s", config.UploadsDir)}
+		args = []string{"/C", fmt.Sprintf("git add %s", strings.Join(paths, " "))}
 	} else {
 		cmd = "git"
-		args = []string{"add", config.UploadsDir}
+		args = append([]string{"add"}, paths...)
 	}
 
-	out, err := exec.Command(cmd, args...).Output()
-	if err != nil {
-		return fmt.Errorf("%s\n%s", string(out), err.Error())
+	if _, err := config.command(ctx, cmd, args...); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// uploaderTrailer is the trailer of a commit naming the user who uploaded its files
+const uploaderTrailer = "Uploaded-by"
+
 // GitCommitFiles commits files according to a specified directory
-func (config *Config) GitCommitFiles() error {
+// The user of the actor of ctx is added to the message as an Uploaded-by trailer
+func (config *Config) GitCommitFiles(ctx context.Context) error {
 	var cmd string
 	var args []string
 
-	if config.OS == "windows" {
+	if user := strings.Join(strings.Fields(actorFrom(ctx).User), " "); user != "" {
+		// The user comes from a request, it is passed to git as an argument and never to cmd
+		cmd = "git"
+		args = []string{"commit", "-m", fmt.Sprintf("upload files to %s", config.UploadsDir), "-m", uploaderTrailer + ": " + user}
+	} else if config.OS == "windows" {
 		cmd = "cmd"
 		args = []string{"/C", fmt.Sprintf("git commit -m upload-files-to%s", config.UploadsDir)}
 	} else {
@@ -111,16 +211,15 @@ func (config *Config) GitCommitFiles() error {
 		args = []string{"commit", "-m", fmt.Sprintf("upload files to %s", config.UploadsDir)}
 	}
 
-	out, err := exec.Command(cmd, args...).Output()
-	if err != nil {
-		return fmt.Errorf("%s\n%s", string(out), err.Error())
+	if _, err := config.command(ctx, cmd, args...); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 // GitPushFiles pushs files to the specified remote and branch
-func (config *Config) GitPushFiles() error {
+func (config *Config) GitPushFiles(ctx context.Context) error {
 	var cmd string
 	var args []string
 
@@ -132,59 +231,68 @@ func (config *Config) GitPushFiles() error {
 		args = []string{"push", config.Remote, config.Branch}
 	}
 
-	out, err := exec.Command(cmd, args...).Output()
-	if err != nil {
-		return fmt.Errorf("%s\n%s", string(out), err.Error())
+	if _, err := config.command(ctx, cmd, args...); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 // GitPushToken pushs files to the specified remote and branch via a token.
-func (config *Config) GitPushToken() error {
+func (config *Config) GitPushToken(ctx context.Context) error {
 	var cmd string
 	var args []string
 
-	gitURLCommand := fmt.Sprintf("remote.%s.url", config.Remote)
+	pushCommand, err := config.TokenURL(ctx)
+	if err != nil {
+		return err
+	}
 
 	if config.OS == "windows" {
 		cmd = "cmd"
-		args = []string{"/C", fmt.Sprintf("git config %s", gitURLCommand)}
+		args = []string{"/C", fmt.Sprintf("git push %s %s", pushCommand, config.Branch)}
 	} else {
 		cmd = "git"
-		args = []string{"config", gitURLCommand}
+		args = []string{"push", pushCommand, config.Branch}
 	}
 
-	out, err := exec.Command(cmd, args...).Output()
-	if err != nil {
-		return fmt.Errorf("Not found git url from git config %s", gitURLCommand)
+	if _, err := config.command(ctx, cmd, args...); err != nil {
+		return err
 	}
 
-	gitURL, isHTTPS, err := splitGitURL(out)
-	if err != nil {
-		return nil
-	}
+	return nil
 
-	var pushCommand string
-	if isHTTPS {
-		pushCommand = fmt.Sprintf("https://oauth2:%s@%s", config.Token, gitURL)
-	} else {
-		pushCommand = fmt.Sprintf("http://oauth2:%s@%s", config.Token, gitURL)
-	}
+}
+
+// TokenURL returns the URL of the specified remote with the token as credentials
+func (config *Config) TokenURL(ctx context.Context) (string, error) {
+	var cmd string
+	var args []string
+
+	gitURLCommand := fmt.Sprintf("remote.%s.url", config.Remote)
 
 	if config.OS == "windows" {
-		args = []string{"/C", fmt.Sprintf("git push %s %s", pushCommand, config.Branch)}
+		cmd = "cmd"
+		args = []string{"/C", fmt.Sprintf("git config %s", gitURLCommand)}
 	} else {
-		args = []string{"push", pushCommand, config.Branch}
+		cmd = "git"
+		args = []string{"config", gitURLCommand}
 	}
 
-	out, err = exec.Command(cmd, args...).Output()
+	out, err := config.command(ctx, cmd, args...)
 	if err != nil {
-		return fmt.Errorf("%s\n%s", string(out), err.Error())
+		return "", fmt.Errorf("Not found git url from git config %s", gitURLCommand)
 	}
 
-	return nil
+	gitURL, isHTTPS, err := splitGitURL(bytes.TrimSpace(out))
+	if err != nil {
+		return "", fmt.Errorf("Cannot use a token with the url of %s: %s", config.Remote, err.Error())
+	}
 
+	if isHTTPS {
+		return fmt.Sprintf("https://oauth2:%s@%s", config.Token, gitURL), nil
+	}
+	return fmt.Sprintf("http://oauth2:%s@%s", config.Token, gitURL), nil
 }
 
 // splitGitURL returns a GitURL for concatenating with token
@@ -257,7 +365,7 @@ func splitGitURL(url []byte) (string, bool, error) {
 }
 
 // ConfigUser configs the user.name and user.email if flags are provided
-func (config *Config) ConfigUser(configType string) error {
+func (config *Config) ConfigUser(ctx context.Context, configType string) error {
 	var configVar string
 
 	switch strings.ToLower(configType) {
@@ -282,75 +390,61 @@ func (config *Config) ConfigUser(configType string) error {
 		args = []string{"config", fmt.Sprintf("user.%s", configType), fmt.Sprintf("\"%s\"", configVar)}
 	}
 
-	out, err := exec.Command(cmd, args...).Output()
-	if err != nil {
-		return fmt.Errorf("%s\n%s", out, err)
+	if _, err := config.command(ctx, cmd, args...); err != nil {
+		return err
 	}
 	return nil
 }
 
 // HandleUpload handles the files uploading function
 func (config *Config) HandleUpload(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	c.Request().ParseMultipartForm(32 << 20)
-	form, err := c.MultipartForm()
+	reader, err := c.Request().MultipartReader()
 	if err != nil {
+		config.audit(ctx, AuditEvent{Action: AuditUpload, Status: AuditFailed, Error: err.Error()})
 		message := fmt.Sprintf("Error when parsing files %s", err.Error())
 		return c.String(http.StatusBadRequest, message)
 	}
-	files := form.File["file"]
 
-	var fullname string
-	for _, file := range files {
-
-		fullname = filepath.Join(".", config.UploadsDir, file.Filename)
-
-		src, err := file.Open()
-		if err != nil {
-			message := fmt.Sprintf("Error when opening %v", file.Filename)
-			return c.String(http.StatusBadRequest, message)
-		}
-		defer src.Close()
-
-		dst, err := os.Create(fullname)
-		if err != nil {
-			message := fmt.Sprintf("Error when opening %v", file.Filename)
-			return c.String(http.StatusBadRequest, message)
-		}
-		defer dst.Close()
-
-		if _, err = io.Copy(dst, src); err != nil {
-			message := fmt.Sprintf("Error when opening %v", file.Filename)
-			return c.String(http.StatusBadRequest, message)
-		}
+	result, err := config.StoreMultipart(ctx, reader, c.Request().Header.Get(HeaderContentSha256))
+	if formErr, ok := err.(*FormError); ok {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": formErr.Error()})
+	}
+	if policyErr, ok := err.(*PolicyError); ok {
+		return c.JSON(policyErr.Status, policyErr)
+	}
+	if checksumErr, ok := err.(*ChecksumError); ok {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": checksumErr.Error(), "file": checksumErr.File, "expected": checksumErr.Expected, "sha256": checksumErr.Sha256})
+	}
+	if scanErr, ok := err.(*ScanError); ok {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": scanErr.Error(), "file": scanErr.File, "scan": scanErr.Results})
+	}
+	if duplicateErr, ok := err.(*DuplicateError); ok {
+		return c.JSON(http.StatusConflict, echo.Map{"error": duplicateErr.Error(), "duplicates": duplicateErr.Duplicates})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
 	}
 
-	return c.String(http.StatusOK, "Files are uploaded")
+	return c.JSON(http.StatusOK, result)
 
 }
 
 // HandlePushFiles runs git add, commit and push
 func (config *Config) HandlePushFiles(c echo.Context) error {
-	err := config.GitAddFile()
-	if err != nil {
-		errMsg := fmt.Sprintf("Error when running git add %s\n\n***************************************************\n%s", config.UploadsDir, err.Error())
-		return c.String(http.StatusExpectationFailed, errMsg)
-	}
-
-	err = config.GitCommitFiles()
-	if err != nil {
-		errMsg := fmt.Sprintf("Error when running git commit\n\n***************************************************\n%s", err.Error())
+	_, err := config.RunPush(c.Request().Context())
+	if stepErr, ok := err.(*StepError); ok {
+		step := stepErr.Step
+		if step == "add" {
+			step = "add " + config.UploadsDir
+		}
+		errMsg := fmt.Sprintf("Error when running git %s\n\n***************************************************\n%s", step, stepErr.Err.Error())
 		return c.String(http.StatusExpectationFailed, errMsg)
 	}
-
-	if config.Token == "" {
-		err = config.GitPushFiles()
-	} else {
-		err = config.GitPushToken()
-	}
 	if err != nil {
-		errMsg := fmt.Sprintf("Error when running git push\n\n***************************************************\n%s", err.Error())
-		return c.String(http.StatusExpectationFailed, errMsg)
+		errMsg := fmt.Sprintf("Error when recording the push job\n\n***************************************************\n%s", err.Error())
+		return c.String(http.StatusInternalServerError, errMsg)
 	}
 
 	return c.Redirect(http.StatusMovedPermanently, "/")
@@ -3,6 +3,11 @@ package gitcommand
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -34,3 +39,66 @@ func TestSplitURL(t *testing.T) {
 		}
 	}
 }
+
+// testRepo creates a repository on the master branch pushed to a bare origin and moves into it,
+// the returned function moves back and removes both
+func testRepo(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() {
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+	}
+
+	repo := filepath.Join(dir, "repo")
+	for _, args := range [][]string{
+		{"init", "-q", "--bare", filepath.Join(dir, "origin.git")},
+		{"init", "-q", repo},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			cleanup()
+			t.Fatal(string(out))
+		}
+	}
+	if err := os.Chdir(repo); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	testGit(t, "config", "user.name", "tester")
+	testGit(t, "config", "user.email", "tester@example.com")
+	testGit(t, "config", "commit.gpgsign", "false")
+	testGit(t, "checkout", "-q", "-b", "master")
+	testGit(t, "remote", "add", "origin", filepath.Join(dir, "origin.git"))
+	testCommit(t, "README", "test repository\n")
+	testGit(t, "push", "-q", "origin", "master")
+
+	return cleanup
+}
+
+// testGit runs git in the current directory and returns its trimmed output
+func testGit(t *testing.T, args ...string) string {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// testCommit writes a file and commits it
+func testCommit(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, "add", path)
+	testGit(t, "commit", "-q", "-m", "add "+path)
+}
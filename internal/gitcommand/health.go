@@ -0,0 +1,190 @@
+package gitcommand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// Check is the result of a diagnostic of the environment
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+	// Fix tells how to solve a failed check
+	Fix string `json:"fix,omitempty"`
+}
+
+// Report is the result of a set of checks
+type Report struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+// checkFunc runs a diagnostic
+type checkFunc func(context.Context) Check
+
+// newReport runs checks and sums them up
+func newReport(ctx context.Context, checks ...checkFunc) Report {
+	report := Report{Status: "ok"}
+
+	for _, check := range checks {
+		result := check(ctx)
+		if !result.OK {
+			report.Status = "fail"
+		} else {
+			result.Fix = ""
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	return report
+}
+
+// HandleHealth reports whether git runs in a repository
+func (config *Config) HandleHealth(c echo.Context) error {
+	return replyReport(c, newReport(c.Request().Context(), config.CheckGit, config.CheckRepository))
+}
+
+// HandleReady reports whether the server can take uploads and push them
+func (config *Config) HandleReady(c echo.Context) error {
+	return replyReport(c, newReport(c.Request().Context(), config.readyChecks()...))
+}
+
+func (config *Config) readyChecks() []checkFunc {
+	return []checkFunc{
+		config.CheckStopping,
+		config.CheckGit,
+		config.CheckLfs,
+		config.CheckRepository,
+		config.CheckRemote,
+		config.CheckUploadsWritable,
+		config.CheckUploadsTracked,
+		config.CheckFreeSpace,
+	}
+}
+
+func replyReport(c echo.Context, report Report) error {
+	if report.Status != "ok" {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// CheckGit checks that git is installed
+func (config *Config) CheckGit(ctx context.Context) Check {
+	out, err := config.git(ctx, "version")
+	return commandCheck("git", out, err, "install git from https://git-scm.com/downloads and add it to your PATH")
+}
+
+// CheckLfs checks that git lfs is installed
+func (config *Config) CheckLfs(ctx context.Context) Check {
+	out, err := config.git(ctx, "lfs", "version")
+	return commandCheck("git-lfs", out, err, "install Git-LFS from https://github.com/git-lfs/git-lfs/releases")
+}
+
+// CheckRepository checks that the working directory is a git repository
+func (config *Config) CheckRepository(ctx context.Context) Check {
+	out, err := config.git(ctx, "rev-parse", "--show-toplevel")
+	return commandCheck("repository", out, err, "run add2git-lfs in your working repository, or git init it")
+}
+
+// CheckRemote checks that Remote has a URL
+func (config *Config) CheckRemote(ctx context.Context) Check {
+	out, err := config.git(ctx, "remote", "get-url", config.Remote)
+	check := commandCheck("remote", out, err, fmt.Sprintf("add the remote with git remote add %s <url>, or use -remote", config.Remote))
+	check.Detail = config.redact(check.Detail)
+	return check
+}
+
+// CheckUploadsWritable checks that files can be created in UploadsDir
+func (config *Config) CheckUploadsWritable(ctx context.Context) Check {
+	check := Check{Name: "folder writable", Detail: config.UploadsDir}
+
+	if _, err := os.Stat(config.UploadsDir); os.IsNotExist(err) {
+		check.OK = true
+		check.Detail = config.UploadsDir + " is created on start"
+		return check
+	}
+
+	// The probe is named like the staged files, so git ignores it and a killed server has it removed on start
+	file, err := ioutil.TempFile(config.UploadsDir, ".probe.*"+tempSuffix)
+	if err != nil {
+		check.Detail = err.Error()
+		check.Fix = fmt.Sprintf("create %s and give write permission on it to the user running add2git-lfs", config.UploadsDir)
+		return check
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	check.OK = true
+	return check
+}
+
+// CheckUploadsTracked checks that files of UploadsDir go through LFS
+func (config *Config) CheckUploadsTracked(ctx context.Context) Check {
+	check := Check{Name: "folder tracked by lfs"}
+
+	if config.LfsThreshold > 0 {
+		check.OK = true
+		check.Detail = fmt.Sprintf("files are routed by size from %d bytes", config.LfsThreshold)
+		return check
+	}
+
+	probe := filepath.ToSlash(config.UploadPath("probe", strings.Repeat("0", 64)))
+	out, err := config.git(ctx, "check-attr", "filter", "--", probe)
+	if err != nil {
+		check.Detail = err.Error()
+	} else {
+		check.Detail = strings.TrimSpace(string(out))
+		check.OK = strings.HasSuffix(check.Detail, ": filter: lfs")
+	}
+	check.Fix = fmt.Sprintf("run git lfs track \"%s\" and commit .gitattributes", config.trackPattern())
+
+	return check
+}
+
+// errFreeSpaceUnsupported is returned by freeSpace on the platforms where the free space cannot be measured
+var errFreeSpaceUnsupported = errors.New("the free space is not measured on this platform")
+
+// CheckFreeSpace checks that the disk of UploadsDir has at least MinFreeSpace bytes available
+func (config *Config) CheckFreeSpace(ctx context.Context) Check {
+	check := Check{Name: "free space"}
+
+	// UploadsDir may not be created yet, its disk is the one of its closest parent
+	dir := config.UploadsDir
+	for _, err := os.Stat(dir); os.IsNotExist(err) && filepath.Dir(dir) != dir; _, err = os.Stat(dir) {
+		dir = filepath.Dir(dir)
+	}
+
+	free, err := freeSpace(dir)
+	if err == errFreeSpaceUnsupported {
+		check.OK = true
+		check.Detail = err.Error()
+		return check
+	}
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+
+	check.OK = free >= config.MinFreeSpace
+	check.Detail = fmt.Sprintf("%d bytes available, %d required", free, config.MinFreeSpace)
+	check.Fix = fmt.Sprintf("free space on the disk of %s", config.UploadsDir)
+	return check
+}
+
+// commandCheck turns the output of a command into a check
+func commandCheck(name string, out []byte, err error, fix string) Check {
+	if err != nil {
+		return Check{Name: name, Detail: strings.TrimSpace(err.Error()), Fix: fix}
+	}
+	return Check{Name: name, OK: true, Detail: strings.TrimSpace(string(out))}
+}
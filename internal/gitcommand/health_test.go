@@ -0,0 +1,58 @@
+package gitcommand
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestNewReport(t *testing.T) {
+	pass := func(context.Context) Check { return Check{Name: "pass", OK: true, Fix: "nothing"} }
+	fail := func(context.Context) Check { return Check{Name: "fail", Fix: "something"} }
+
+	report := newReport(context.Background(), pass)
+	if report.Status != "ok" || report.Checks[0].Fix != "" {
+		t.Fatal(report)
+	}
+
+	report = newReport(context.Background(), pass, fail)
+	if report.Status != "fail" || len(report.Checks) != 2 || report.Checks[1].Fix != "something" {
+		t.Fatal(report)
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	config := &Config{UploadsDir: "."}
+	if check := config.CheckFreeSpace(context.Background()); !check.OK {
+		t.Fatal(check)
+	}
+
+	config.MinFreeSpace = 1 << 62
+	if check := config.CheckFreeSpace(context.Background()); check.OK {
+		t.Fatal(check)
+	}
+}
+
+func TestCheckUploadsWritable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &Config{UploadsDir: dir}
+	if check := config.CheckUploadsWritable(context.Background()); !check.OK {
+		t.Fatal(check)
+	}
+
+	// A probe left by a killed server is an abandoned temporary file
+	probe, err := ioutil.TempFile(dir, ".probe.*"+tempSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe.Close()
+	if removed, err := config.RemoveTemps(); err != nil || len(removed) != 1 || removed[0] != probe.Name() {
+		t.Fatalf("got %v, %v", removed, err)
+	}
+}
@@ -0,0 +1,127 @@
+package gitcommand
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// historyFormat separates commits with \x1e and their fields with \x1f, the last one being the Uploaded-by trailer
+const historyFormat = "--format=%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%(trailers:key=" + uploaderTrailer + ",valueonly,separator=%x2c )"
+
+// Commit is a commit touching UploadsDir
+type Commit struct {
+	Commit  string    `json:"commit"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	// Uploader is the user who uploaded the files, when the tool made the commit for a known user
+	Uploader string   `json:"uploader,omitempty"`
+	Files    []string `json:"files"`
+	// Job is the push job that made the commit, if it was made by the tool
+	Job *Job `json:"job,omitempty"`
+}
+
+// HandleHistory lists the commits touching UploadsDir on the branch with their push job
+// The path query parameter restricts it to a file, the job one to an upload
+func (config *Config) HandleHistory(c echo.Context) error {
+	ref := c.QueryParam("ref")
+	if ref == "" {
+		ref = config.Branch
+	}
+
+	name := config.UploadsDir
+	if c.QueryParam("path") != "" {
+		var ok bool
+		if name, ok = config.repositoryPath(c.QueryParam("path")); !ok {
+			return c.JSON(http.StatusNotFound, echo.Map{"error": "file is not in " + config.UploadsDir})
+		}
+	}
+
+	commits, err := config.History(c.Request().Context(), ref, name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+
+	jobs, err := config.ReadJobs(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, withJobs(commits, jobs, c.QueryParam("job")))
+}
+
+// HandleJobs lists the push jobs of the journal, newest first, including those that failed before committing
+func (config *Config) HandleJobs(c echo.Context) error {
+	jobs, err := config.ReadJobs(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, lastJobs(jobs, -1))
+}
+
+// History returns the commits touching a path on a ref, newest first
+func (config *Config) History(ctx context.Context, ref, name string) ([]Commit, error) {
+	if !validRef(ref) {
+		return nil, errInvalidRef(ref)
+	}
+
+	out, err := config.git(ctx, "-c", "core.quotepath=off", "log", historyFormat, "--name-only", ref, "--", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHistory(string(out)), nil
+}
+
+// parseHistory parses a git log in historyFormat with --name-only
+func parseHistory(out string) []Commit {
+	commits := []Commit{}
+
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		header := strings.Split(lines[0], "\x1f")
+		if len(header) != 6 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, header[3])
+		commit := Commit{Commit: header[0], Author: header[1], Email: header[2], Date: date, Subject: header[4],
+			Uploader: header[5], Files: []string{}}
+
+		for _, name := range lines[1:] {
+			if name = strings.TrimSpace(name); name != "" {
+				commit.Files = append(commit.Files, path.Clean(name))
+			}
+		}
+		commits = append(commits, commit)
+	}
+
+	return commits
+}
+
+// withJobs attaches to each commit the job that made it, keeping only those of jobID if it is not empty
+func withJobs(commits []Commit, jobs []Job, jobID string) []Commit {
+	byCommit := make(map[string]*Job)
+	for i := range jobs {
+		if jobs[i].Commit != "" {
+			byCommit[jobs[i].Commit] = &jobs[i]
+		}
+	}
+
+	matches := []Commit{}
+	for _, commit := range commits {
+		commit.Job = byCommit[commit.Commit]
+		if jobID != "" && (commit.Job == nil || commit.Job.ID != jobID) {
+			continue
+		}
+		matches = append(matches, commit)
+	}
+
+	return matches
+}
@@ -0,0 +1,63 @@
+package gitcommand
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseHistory(t *testing.T) {
+	out := "\x1eaaaa\x1fAlice\x1falice@example.com\x1f2019-09-07T12:00:00+02:00\x1fupload files to sample-files\x1fcarol\n\nsample-files/b.txt\n" +
+		"\x1ebbbb\x1fBob\x1fbob@example.com\x1f2019-09-06T12:00:00+02:00\x1finitial\x1f\n\nsample-files/a.txt\nsample-files/b.txt\n"
+
+	commits := parseHistory(out)
+	if len(commits) != 2 || commits[0].Commit != "aaaa" || commits[0].Uploader != "carol" || commits[1].Subject != "initial" {
+		t.Fatal(commits)
+	}
+	if !reflect.DeepEqual(commits[1].Files, []string{"sample-files/a.txt", "sample-files/b.txt"}) {
+		t.Fatal(commits[1].Files)
+	}
+	if !commits[0].Date.Equal(time.Date(2019, 9, 7, 10, 0, 0, 0, time.UTC)) {
+		t.Fatal(commits[0].Date)
+	}
+}
+
+func TestWithJobs(t *testing.T) {
+	commits := []Commit{{Commit: "aaaa"}, {Commit: "bbbb"}}
+	jobs := []Job{{ID: "1", Commit: "bbbb", Pushed: true}, {ID: "2", Error: "git commit: nothing to commit"}}
+
+	all := withJobs(commits, jobs, "")
+	if len(all) != 2 || all[0].Job != nil || all[1].Job.ID != "1" {
+		t.Fatal(all)
+	}
+
+	if one := withJobs(commits, jobs, "1"); len(one) != 1 || one[0].Commit != "bbbb" {
+		t.Fatal(one)
+	}
+}
+
+func TestHistoryUploader(t *testing.T) {
+	defer testRepo(t)()
+	testCommit(t, attributesFile, "")
+	testGit(t, "push", "-q", "origin", "master")
+
+	ctx := WithActor(context.Background(), Actor{User: "carol"})
+	config := &Config{Branch: "master", Remote: "origin", UploadsDir: "sample-files"}
+	if err := config.ExcludeTemps(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.StoreFiles(ctx, []Source{stringSource("a.txt", "a")}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The git author is the configured user, the uploader is kept in the job and in a trailer of the commit
+	job, err := config.RunPush(ctx)
+	if err != nil || job.User != "carol" {
+		t.Fatalf("got %+v, %v", job, err)
+	}
+	commits, err := config.History(ctx, "master", config.UploadsDir)
+	if err != nil || len(commits) != 1 || commits[0].Commit != job.Commit || commits[0].Uploader != "carol" {
+		t.Fatalf("got %+v, %v", commits, err)
+	}
+}
@@ -0,0 +1,297 @@
+package gitcommand
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// jobsFile is the journal of push jobs in the state directory
+	jobsFile = "jobs.jsonl"
+
+	// maxJobsSize is the size in bytes from which the jobs journal is compacted
+	maxJobsSize = 1 << 20
+
+	// keepJobs is the number of last jobs a compacted journal keeps, besides those Recover needs
+	keepJobs = 1000
+)
+
+// Job is a run of git add, commit and push over the pending files
+// It is journaled when it starts, once committed and when it finishes, a job never finished was interrupted
+// Commit is the pushed HEAD, Committed tells whether the job made it or only pushed earlier commits
+type Job struct {
+	ID        string    `json:"id"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Files     []string  `json:"files"`
+	User      string    `json:"user,omitempty"`
+	Commit    string    `json:"commit,omitempty"`
+	Committed bool      `json:"committed,omitempty"`
+	Pushed    bool      `json:"pushed"`
+	Step      string    `json:"step,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// StepError is an error of a git step of a push job
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("git %s: %s", e.Step, e.Err.Error())
+}
+
+// RunPush runs git add, commit and push over the pending files and records the job in the journal
+// Without pending files, the commits left unpushed by a failed or interrupted job are pushed
+// The job, its commit and the audit log of the commit and the push record the actor of ctx
+// Jobs run one at a time, the others wait in queue
+func (config *Config) RunPush(ctx context.Context) (*Job, error) {
+	config.Metrics.queue(1)
+	defer config.Metrics.queue(-1)
+	config.pushMu.Lock()
+	defer config.pushMu.Unlock()
+
+	job := &Job{ID: newJobID(), Started: time.Now().UTC(), User: actorFrom(ctx).User}
+	if err := config.recordJob(ctx, job); err != nil {
+		return job, err
+	}
+
+	err := config.runPush(ctx, job)
+	if stepErr, ok := err.(*StepError); ok {
+		job.Step = stepErr.Step
+		config.Metrics.pushFailed(stepErr.Step)
+	}
+	if err != nil {
+		job.Error = err.Error()
+	}
+	job.Finished = time.Now().UTC()
+
+	if job.Committed {
+		config.audit(ctx, AuditEvent{Action: AuditCommit, Status: AuditOK, Files: job.Files, Commit: job.Commit, Job: job.ID})
+	}
+	push := AuditEvent{Action: AuditPush, Status: AuditOK, Files: job.Files, Commit: job.Commit, Job: job.ID, Error: job.Error}
+	if err != nil {
+		push.Status = AuditFailed
+	}
+	config.audit(ctx, push)
+
+	if recordErr := config.recordJob(ctx, job); recordErr != nil && err == nil {
+		err = recordErr
+	}
+	if job.Pushed {
+		if forgetErr := config.forgetUploads(ctx, job.Files); forgetErr != nil && err == nil {
+			err = forgetErr
+		}
+	}
+
+	return job, err
+}
+
+func (config *Config) runPush(ctx context.Context, job *Job) error {
+	pending, err := config.PendingFiles(ctx)
+	if err != nil {
+		return &StepError{"status", err}
+	}
+	var lfsBytes int64
+	for path, size := range pending {
+		job.Files = append(job.Files, filepath.ToSlash(path))
+		if config.isLfs(path, size) {
+			lfsBytes += size
+		}
+	}
+	sort.Strings(job.Files)
+
+	unpushed, err := config.UnpushedCommits(ctx)
+	if err != nil {
+		return &StepError{"rev-list", err}
+	}
+
+	if len(pending) > 0 || len(unpushed) == 0 {
+		start := time.Now()
+		if err := config.GitAddFile(ctx); err != nil {
+			return &StepError{"add", err}
+		}
+		config.Metrics.step("add", start)
+
+		start = time.Now()
+		if err := config.GitCommitFiles(ctx); err != nil {
+			return &StepError{"commit", err}
+		}
+		config.Metrics.step("commit", start)
+		job.Committed = true
+	}
+
+	out, err := config.git(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return &StepError{"rev-parse", err}
+	}
+	job.Commit = strings.TrimSpace(string(out))
+	if err := config.recordJob(ctx, job); err != nil {
+		config.loggerFor(ctx).Warn("cannot journal the commit of a push job", "job", job.ID, "error", err)
+	}
+
+	start := time.Now()
+	if config.Token == "" {
+		err = config.GitPushFiles(ctx)
+	} else {
+		err = config.GitPushToken(ctx)
+	}
+	if err != nil {
+		return &StepError{"push", err}
+	}
+	config.Metrics.step("push", start)
+	config.Metrics.lfsBytes("push", lfsBytes)
+	job.Pushed = true
+
+	return nil
+}
+
+// newJobID returns a unique, time-ordered job ID
+func newJobID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// StateDir returns the directory of the files the tool keeps in the git directory, creating it if needed
+func (config *Config) StateDir(ctx context.Context) (string, error) {
+	out, err := config.git(ctx, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(strings.TrimSpace(string(out)), "add2git-lfs")
+	return dir, os.MkdirAll(dir, os.ModePerm)
+}
+
+// recordJob appends the state of a job to the journal, compacting it once it reaches maxJobsSize
+// Jobs are recorded one at a time, by a push job or by the recovery before the server starts
+func (config *Config) recordJob(ctx context.Context, job *Job) error {
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, jobsFile)
+
+	line, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	var size int64
+	if info, statErr := file.Stat(); statErr == nil {
+		size = info.Size()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || size < maxJobsSize {
+		return err
+	}
+
+	return compactJobs(path)
+}
+
+// compactJobs rewrites the journal at path with the last state of the jobs compactedJobs keeps
+func compactJobs(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	jobs, err := parseJobs(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	var content []byte
+	for _, job := range compactedJobs(jobs) {
+		line, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		content = append(append(content, line...), '\n')
+	}
+
+	return writeAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
+
+// compactedJobs returns the last keepJobs jobs with the older ones Recover needs: the unfinished jobs
+// and those after the last pushed one, whose commits may not be pushed
+func compactedJobs(jobs []Job) []Job {
+	lastPushed := -1
+	for i, job := range jobs {
+		if job.Pushed {
+			lastPushed = i
+		}
+	}
+
+	var kept []Job
+	for i, job := range jobs {
+		if i >= len(jobs)-keepJobs || i > lastPushed || job.Finished.IsZero() {
+			kept = append(kept, job)
+		}
+	}
+	return kept
+}
+
+// ReadJobs returns the jobs of the journal, oldest first
+func (config *Config) ReadJobs(ctx context.Context) ([]Job, error) {
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(dir, jobsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseJobs(file)
+}
+
+// parseJobs returns the jobs of a journal in the order they started, with their last state
+func parseJobs(r io.Reader) ([]Job, error) {
+	var jobs []Job
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var job Job
+		if err := json.Unmarshal(scanner.Bytes(), &job); err != nil {
+			continue
+		}
+		if i, ok := index[job.ID]; ok {
+			jobs[i] = job
+			continue
+		}
+		index[job.ID] = len(jobs)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, scanner.Err()
+}
@@ -0,0 +1,155 @@
+package gitcommand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJobs(t *testing.T) {
+	journal := `{"id":"1","started":"2019-09-07T12:00:00Z","finished":"0001-01-01T00:00:00Z","files":null,"pushed":false}
+{"id":"2","started":"2019-09-07T12:01:00Z","finished":"0001-01-01T00:00:00Z","files":null,"pushed":false}
+{"id":"1","started":"2019-09-07T12:00:00Z","finished":"2019-09-07T12:00:05Z","files":["sample-files/a.txt"],"commit":"aaaa","pushed":true}
+not json
+`
+
+	jobs, err := parseJobs(strings.NewReader(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "1" || !jobs[0].Pushed || jobs[1].ID != "2" {
+		t.Fatal(jobs)
+	}
+	if jobs[0].Finished.IsZero() || !jobs[1].Finished.IsZero() {
+		t.Fatal("job 2 should be the only interrupted one")
+	}
+}
+
+func TestCompactedJobs(t *testing.T) {
+	started := time.Date(2019, 9, 7, 12, 0, 0, 0, time.UTC)
+
+	var jobs []Job
+	for i := 0; i < keepJobs+10; i++ {
+		job := Job{ID: fmt.Sprint(i), Started: started, Finished: started, Pushed: true}
+		switch i {
+		case 3:
+			// Interrupted
+			job.Finished = time.Time{}
+		case keepJobs + 5, keepJobs + 6:
+			// Failed pushes at the end
+			job.Pushed = false
+		}
+		jobs = append(jobs, job)
+	}
+	jobs[keepJobs+9].Pushed = false
+
+	kept := compactedJobs(jobs)
+	if len(kept) != keepJobs+1 || kept[0].ID != "3" || kept[1].ID != "10" || kept[len(kept)-1].ID != fmt.Sprint(keepJobs+9) {
+		t.Fatalf("got %d jobs from %s to %s", len(kept), kept[0].ID, kept[len(kept)-1].ID)
+	}
+
+	// Jobs after the last push may have left unpushed commits, none is dropped
+	for i := range jobs {
+		jobs[i].Pushed = i < 5
+	}
+	if kept := compactedJobs(jobs); len(kept) != len(jobs)-4 || kept[0].ID != "3" {
+		t.Fatalf("got %d jobs from %s", len(kept), kept[0].ID)
+	}
+}
+
+func TestRecordJobCompacts(t *testing.T) {
+	defer testRepo(t)()
+
+	ctx := context.Background()
+	config := &Config{}
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A journal of pushed jobs, each recorded when it started and when it finished
+	var journal bytes.Buffer
+	started := time.Date(2019, 9, 7, 12, 0, 0, 0, time.UTC)
+	files := []string{"sample-files/" + strings.Repeat("x", 200)}
+	for i := 0; journal.Len() < maxJobsSize; i++ {
+		job := Job{ID: fmt.Sprintf("%06d", i), Started: started, Files: files}
+		line, _ := json.Marshal(job)
+		journal.Write(append(line, '\n'))
+		job.Finished, job.Commit, job.Pushed = started, "aaaa", true
+		line, _ = json.Marshal(job)
+		journal.Write(append(line, '\n'))
+	}
+	path := filepath.Join(dir, jobsFile)
+	if err := ioutil.WriteFile(path, journal.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.recordJob(ctx, &Job{ID: "last", Started: started}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Size() >= maxJobsSize {
+		t.Fatalf("the journal should be compacted, got %v, %v", info.Size(), err)
+	}
+	jobs, err := config.ReadJobs(ctx)
+	if err != nil || len(jobs) != keepJobs || jobs[len(jobs)-1].ID != "last" || !jobs[0].Pushed {
+		t.Fatalf("got %d jobs, %v", len(jobs), err)
+	}
+}
+
+func TestRunPushAuditsCommit(t *testing.T) {
+	defer testRepo(t)()
+	testCommit(t, attributesFile, "")
+	testGit(t, "push", "-q", "origin", "master")
+
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	audit, err := OpenAuditLog(filepath.Join(dir, auditFile), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	config := &Config{Branch: "master", Remote: "origin", UploadsDir: "sample-files", Audit: audit}
+	if err := config.ExcludeTemps(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A job committing the pending files records the commit and the push
+	if _, err := config.StoreFiles(ctx, []Source{stringSource("a.txt", "a")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if job, err := config.RunPush(ctx); err != nil || !job.Committed {
+		t.Fatalf("got %+v, %v", job, err)
+	}
+
+	// A job pushing an earlier commit only records the push
+	testCommit(t, "sample-files/b.txt", "b")
+	if job, err := config.RunPush(ctx); err != nil || job.Committed || !job.Pushed {
+		t.Fatalf("got %+v, %v", job, err)
+	}
+
+	events, err := audit.Query(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, event := range events {
+		actions = append(actions, event.Action)
+	}
+	if want := []string{AuditUpload, AuditCommit, AuditPush, AuditPush}; !reflect.DeepEqual(actions, want) {
+		t.Fatal(actions)
+	}
+}
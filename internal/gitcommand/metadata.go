@@ -0,0 +1,137 @@
+package gitcommand
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Metadata formats for sidecar files
+const (
+	MetadataNone = "none"
+	MetadataJSON = "json"
+	MetadataYAML = "yaml"
+)
+
+// metadataSuffixes are appended to the path of a file to name its sidecar, by format
+var metadataSuffixes = map[string]string{
+	MetadataJSON: ".meta.json",
+	MetadataYAML: ".meta.yaml",
+}
+
+// Metadata is the sidecar committed next to an uploaded file
+type Metadata struct {
+	Filename    string       `json:"filename" yaml:"filename"`
+	Sha256      string       `json:"sha256" yaml:"sha256"`
+	Size        int64        `json:"size" yaml:"size"`
+	MimeType    string       `json:"mime_type,omitempty" yaml:"mime_type,omitempty"`
+	Uploader    string       `json:"uploader,omitempty" yaml:"uploader,omitempty"`
+	Uploaded    time.Time    `json:"uploaded" yaml:"uploaded"`
+	Tags        []string     `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Description string       `json:"description,omitempty" yaml:"description,omitempty"`
+	Archive     string       `json:"archive,omitempty" yaml:"archive,omitempty"`
+	ZipPassword string       `json:"zip_password,omitempty" yaml:"zip_password,omitempty"`
+	Scan        []ScanResult `json:"scan,omitempty" yaml:"scan,omitempty"`
+}
+
+// ValidMetadata tells whether format is a known metadata format
+func ValidMetadata(format string) bool {
+	return format == MetadataNone || format == MetadataJSON || format == MetadataYAML
+}
+
+// metadataFormat returns the format of sidecar files, content-addressed files always get one to keep their name
+func (config *Config) metadataFormat() string {
+	if _, ok := metadataSuffixes[config.Metadata]; ok {
+		return config.Metadata
+	}
+	if config.contentAddressed() {
+		return MetadataJSON
+	}
+	return MetadataNone
+}
+
+// isMetadata tells whether path is a sidecar file
+func isMetadata(path string) bool {
+	for _, suffix := range metadataSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadMetadata describes an uploaded file from the fields of the upload form
+func (config *Config) uploadMetadata(file upload, values map[string][]string, uploaded time.Time) Metadata {
+	uploader := formValue(values, "uploader")
+	if uploader == "" {
+		uploader = config.User
+	}
+
+	meta := Metadata{
+		Filename:    file.Filename,
+		Sha256:      file.Oid,
+		Size:        file.Size,
+		MimeType:    file.Type,
+		Uploader:    uploader,
+		Uploaded:    uploaded.UTC(),
+		Tags:        SplitList(formValue(values, "tags")),
+		Description: formValue(values, "description"),
+		Archive:     file.Archive,
+		Scan:        file.Scan,
+	}
+
+	if config.ScanAction == ScanTag && len(detectedBy(file.Scan)) > 0 && !containsTag(meta.Tags, detectedTag) {
+		meta.Tags = append(meta.Tags, detectedTag)
+	}
+
+	// The sidecar of a zip describes the file it holds
+	if file.Wrapped != nil {
+		meta.Sha256 = file.Wrapped.Oid
+		meta.Size = file.Wrapped.Size
+		meta.ZipPassword = config.ZipPassword
+	}
+
+	return meta
+}
+
+// formValue returns the first value of a form field
+func formValue(values map[string][]string, key string) string {
+	if len(values[key]) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[key][0])
+}
+
+// encodeMetadata returns the content of a sidecar in the given format
+func encodeMetadata(meta Metadata, format string) ([]byte, error) {
+	if format == MetadataYAML {
+		return yaml.Marshal(meta)
+	}
+
+	content, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// writeMetadata writes the sidecar of the file at path
+func (config *Config) writeMetadata(path string, meta Metadata) error {
+	format := config.metadataFormat()
+	if format == MetadataNone {
+		return nil
+	}
+
+	content, err := encodeMetadata(meta, format)
+	if err != nil {
+		return err
+	}
+
+	return writeAtomic(path+metadataSuffixes[format], func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
@@ -0,0 +1,48 @@
+package gitcommand
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadMetadata(t *testing.T) {
+	config := &Config{User: "saguywalker"}
+	file := upload{Source: Source{Filename: "hello.txt", Size: 5}, Oid: helloOid, Path: "sample-files/hello.txt", Type: "text/plain"}
+	values := map[string][]string{"tags": {"emotet, dropper"}, "description": {" first stage "}}
+	uploaded := time.Date(2019, 9, 7, 12, 0, 0, 0, time.UTC)
+
+	want := Metadata{
+		Filename:    "hello.txt",
+		Sha256:      helloOid,
+		Size:        5,
+		MimeType:    "text/plain",
+		Uploader:    "saguywalker",
+		Uploaded:    uploaded,
+		Tags:        []string{"emotet", "dropper"},
+		Description: "first stage",
+	}
+	if meta := config.uploadMetadata(file, values, uploaded); !reflect.DeepEqual(meta, want) {
+		t.Fatal(meta)
+	}
+
+	values["uploader"] = []string{"analyst"}
+	if meta := config.uploadMetadata(file, values, uploaded); meta.Uploader != "analyst" {
+		t.Fatal(meta.Uploader)
+	}
+}
+
+func TestEncodeMetadata(t *testing.T) {
+	meta := Metadata{Filename: "hello.txt", Sha256: helloOid, Tags: []string{"emotet"}}
+
+	content, err := encodeMetadata(meta, MetadataYAML)
+	if err != nil || !strings.Contains(string(content), "filename: hello.txt\n") || !strings.Contains(string(content), "- emotet\n") {
+		t.Fatal(string(content), err)
+	}
+
+	content, err = encodeMetadata(meta, MetadataJSON)
+	if err != nil || !strings.Contains(string(content), `"sha256": "`+helloOid+`"`) {
+		t.Fatal(string(content), err)
+	}
+}
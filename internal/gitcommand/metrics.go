@@ -0,0 +1,87 @@
+package gitcommand
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are the Prometheus collectors of the server, a nil Metrics records nothing
+type Metrics struct {
+	Uploads      prometheus.Counter
+	UploadBytes  prometheus.Counter
+	StepDuration *prometheus.HistogramVec
+	PushFailures *prometheus.CounterVec
+	QueueDepth   prometheus.Gauge
+	LfsBytes     *prometheus.CounterVec
+}
+
+// NewMetrics creates the collectors and registers them
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Uploads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "add2git_lfs_uploads_total",
+			Help: "Number of uploaded files.",
+		}),
+		UploadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "add2git_lfs_upload_bytes_total",
+			Help: "Size of uploaded files in bytes.",
+		}),
+		StepDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "add2git_lfs_git_step_duration_seconds",
+			Help:    "Duration of the git steps of push jobs.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+		}, []string{"step"}),
+		PushFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "add2git_lfs_push_failures_total",
+			Help: "Number of failed push jobs by the git step that failed.",
+		}, []string{"reason"}),
+		QueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "add2git_lfs_push_queue_depth",
+			Help: "Number of push jobs running or waiting for another one.",
+		}),
+		LfsBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "add2git_lfs_lfs_bytes_total",
+			Help: "Size in bytes of LFS files in successful pushes and of LFS objects served.",
+		}, []string{"direction"}),
+	}
+
+	registerer.MustRegister(m.Uploads, m.UploadBytes, m.StepDuration, m.PushFailures, m.QueueDepth, m.LfsBytes)
+	return m
+}
+
+func (m *Metrics) upload(size int64) {
+	if m == nil {
+		return
+	}
+	m.Uploads.Inc()
+	m.UploadBytes.Add(float64(size))
+}
+
+func (m *Metrics) step(step string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.StepDuration.WithLabelValues(step).Observe(time.Since(start).Seconds())
+}
+
+func (m *Metrics) pushFailed(reason string) {
+	if m == nil {
+		return
+	}
+	m.PushFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) queue(delta float64) {
+	if m == nil {
+		return
+	}
+	m.QueueDepth.Add(delta)
+}
+
+func (m *Metrics) lfsBytes(direction string, size int64) {
+	if m == nil {
+		return
+	}
+	m.LfsBytes.WithLabelValues(direction).Add(float64(size))
+}
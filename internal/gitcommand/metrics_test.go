@@ -0,0 +1,33 @@
+package gitcommand
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics(prometheus.NewRegistry())
+
+	m.upload(5)
+	m.upload(7)
+	m.step("push", time.Now())
+	m.pushFailed("push")
+	m.lfsBytes("push", 12)
+
+	if testutil.ToFloat64(m.Uploads) != 2 || testutil.ToFloat64(m.UploadBytes) != 12 {
+		t.Fatal("uploads are not counted")
+	}
+	if testutil.ToFloat64(m.PushFailures.WithLabelValues("push")) != 1 || testutil.ToFloat64(m.LfsBytes.WithLabelValues("push")) != 12 {
+		t.Fatal("pushes are not counted")
+	}
+	if testutil.CollectAndCount(m.StepDuration) != 1 {
+		t.Fatal("steps are not observed")
+	}
+
+	var disabled *Metrics
+	disabled.upload(5)
+	disabled.queue(1)
+}
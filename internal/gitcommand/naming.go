@@ -0,0 +1,55 @@
+package gitcommand
+
+import (
+	"path/filepath"
+)
+
+// Naming modes for uploaded files
+const (
+	NamingOriginal = "original"
+	NamingSha256   = "sha256"
+	NamingSharded  = "sharded"
+)
+
+// upload is a file of an upload request with its SHA-256, the path it is stored at, its sniffed type
+// and the temporary file it is staged in until it is stored, Archive is the archive it was extracted from, if any
+// Once wrapped into a zip, Oid and Size are the ones of the zip and Wrapped keeps the ones of the file
+// Scan holds the results of the Scanners
+type upload struct {
+	Source
+	Oid     string
+	Path    string
+	Type    string
+	Temp    string
+	Archive string
+	Wrapped *wrapped
+	Scan    []ScanResult
+}
+
+// ValidNaming tells whether mode is a known naming mode
+func ValidNaming(mode string) bool {
+	return mode == NamingOriginal || mode == NamingSha256 || mode == NamingSharded
+}
+
+// contentAddressed tells whether files are stored by their SHA-256 instead of their name
+func (config *Config) contentAddressed() bool {
+	return config.Naming == NamingSha256 || config.Naming == NamingSharded
+}
+
+// trackPattern returns the LFS pattern covering the files of UploadsDir, including those of its subdirectories
+// made by sharding or by extracted archives
+func (config *Config) trackPattern() string {
+	return config.UploadsDir + "/**"
+}
+
+// UploadPath returns where a file with the given name and SHA-256 is stored
+func (config *Config) UploadPath(filename, oid string) string {
+	switch config.Naming {
+	case NamingSha256:
+		return filepath.Join(config.UploadsDir, oid)
+	case NamingSharded:
+		return filepath.Join(config.UploadsDir, oid[:2], oid[2:4], oid)
+	default:
+		return filepath.Join(config.UploadsDir, filepath.Base(filename))
+	}
+}
@@ -0,0 +1,28 @@
+package gitcommand
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const helloOid = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+var namingCases = []struct {
+	naming   string
+	filename string
+	out      string
+}{
+	{NamingOriginal, "hello.txt", "sample-files/hello.txt"},
+	{NamingOriginal, "../../etc/hello.txt", "sample-files/hello.txt"},
+	{NamingSha256, "hello.txt", "sample-files/" + helloOid},
+	{NamingSharded, "hello.txt", "sample-files/2c/f2/" + helloOid},
+}
+
+func TestUploadPath(t *testing.T) {
+	for _, c := range namingCases {
+		config := &Config{UploadsDir: "sample-files", Naming: c.naming}
+		if out := config.UploadPath(c.filename, helloOid); out != filepath.FromSlash(c.out) {
+			t.Fatalf("%s: got %s, want %s", c.naming, out, c.out)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package gitcommand
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Policy limits what can be uploaded to a target
+// Zero values and empty lists disable the corresponding check
+type Policy struct {
+	MaxFileSize       int64
+	MaxPushSize       int64
+	MaxFiles          int
+	AllowedExtensions []string
+	BlockedExtensions []string
+	AllowedTypes      []string
+	BlockedTypes      []string
+
+	// MaxArchiveEntries and MaxArchiveSize limit the files and bytes an extracted archive yields
+	MaxArchiveEntries int
+	MaxArchiveSize    int64
+}
+
+// PolicyError is returned when an upload violates the Policy
+type PolicyError struct {
+	Status  int    `json:"-"`
+	File    string `json:"file,omitempty"`
+	Message string `json:"error"`
+}
+
+func (e *PolicyError) Error() string {
+	if e.File == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// SplitList splits a comma-separated flag value, dropping empty items
+func SplitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// checkUpload validates staged files against the Policy, counting files already waiting for the next push
+func (config *Config) checkUpload(ctx context.Context, files []upload) error {
+	policy := config.Policy
+
+	pending, err := config.PendingFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	count, total := pushSize(pending)
+
+	for _, file := range files {
+		if size, ok := pending[filepath.Clean(file.Path)]; ok {
+			count--
+			total -= size
+		}
+		count++
+		total += file.Size
+
+		if policy.MaxFileSize > 0 && file.Size > policy.MaxFileSize {
+			return &PolicyError{http.StatusRequestEntityTooLarge, file.Filename, fmt.Sprintf("file is larger than %d bytes", policy.MaxFileSize)}
+		}
+
+		if err := config.checkExtension(file.Filename); err != nil {
+			return err
+		}
+
+		if !allowed(file.Type, policy.AllowedTypes, policy.BlockedTypes, matchType) {
+			return &PolicyError{http.StatusUnsupportedMediaType, file.Filename, fmt.Sprintf("type %q is not allowed", file.Type)}
+		}
+	}
+
+	if policy.MaxFiles > 0 && count > policy.MaxFiles {
+		return policy.tooManyFiles()
+	}
+
+	if policy.MaxPushSize > 0 && total > policy.MaxPushSize {
+		return policy.pushTooLarge()
+	}
+
+	return nil
+}
+
+func (policy Policy) tooManyFiles() error {
+	return &PolicyError{http.StatusRequestEntityTooLarge, "", fmt.Sprintf("a push cannot contain more than %d files", policy.MaxFiles)}
+}
+
+func (policy Policy) pushTooLarge() error {
+	return &PolicyError{http.StatusRequestEntityTooLarge, "", fmt.Sprintf("a push cannot be larger than %d bytes", policy.MaxPushSize)}
+}
+
+// pushQuota counts the files and bytes of the next push while an upload is read, starting from the pending files,
+// so an upload over MaxFiles or MaxPushSize is refused without being read to the end
+type pushQuota struct {
+	policy  Policy
+	pending map[string]int64
+	files   int
+	bytes   int64
+}
+
+// newPushQuota returns the quota of the next push, without any limit in the Policy it counts nothing
+func (config *Config) newPushQuota(ctx context.Context) (*pushQuota, error) {
+	q := &pushQuota{policy: config.Policy}
+	if q.policy.MaxFiles <= 0 && q.policy.MaxPushSize <= 0 {
+		return q, nil
+	}
+
+	var err error
+	if q.pending, err = config.PendingFiles(ctx); err != nil {
+		return nil, err
+	}
+	q.files, q.bytes = pushSize(q.pending)
+	return q, nil
+}
+
+// pushSize counts the pending files and their bytes toward MaxFiles and MaxPushSize, sidecars aside
+func pushSize(pending map[string]int64) (int, int64) {
+	var count int
+	var total int64
+	for path, size := range pending {
+		if isMetadata(path) {
+			continue
+		}
+		count++
+		total += size
+	}
+	return count, total
+}
+
+// add counts a new file, whose content is then read through the returned reader
+func (q *pushQuota) add(r io.Reader) (io.Reader, error) {
+	q.files++
+	if q.policy.MaxFiles > 0 && q.files > q.policy.MaxFiles {
+		return nil, q.policy.tooManyFiles()
+	}
+	return &quotaReader{q, r}, nil
+}
+
+// staged stops counting the pending file a staged file replaces
+func (q *pushQuota) staged(file upload) {
+	if size, ok := q.pending[filepath.Clean(file.Path)]; ok {
+		delete(q.pending, filepath.Clean(file.Path))
+		q.files--
+		q.bytes -= size
+	}
+}
+
+// quotaReader counts the bytes read from a file against MaxPushSize
+type quotaReader struct {
+	q *pushQuota
+	r io.Reader
+}
+
+func (r *quotaReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.q.bytes += int64(n)
+	if r.q.policy.MaxPushSize > 0 && r.q.bytes > r.q.policy.MaxPushSize {
+		return n, r.q.policy.pushTooLarge()
+	}
+	return n, err
+}
+
+// checkExtension validates the extension of a file name against the Policy
+func (config *Config) checkExtension(filename string) error {
+	policy := config.Policy
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !allowed(ext, policy.AllowedExtensions, policy.BlockedExtensions, matchExtension) {
+		return &PolicyError{http.StatusUnsupportedMediaType, filename, fmt.Sprintf("extension %q is not allowed", ext)}
+	}
+
+	return nil
+}
+
+// PendingFiles returns the files in UploadsDir waiting for the next commit with their sizes
+func (config *Config) PendingFiles(ctx context.Context) (map[string]int64, error) {
+	// Uploads look at the pending files while a push may run, the index must not be locked to refresh it
+	out, err := config.git(ctx, "--no-optional-locks", "status", "--porcelain", "-z", "--untracked-files=all", "--", config.UploadsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]int64)
+	for _, path := range parseStatus(out) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		pending[filepath.Clean(path)] = info.Size()
+	}
+
+	return pending, nil
+}
+
+// parseStatus returns the paths of a git status --porcelain -z output
+func parseStatus(out []byte) []string {
+	var paths []string
+
+	entries := bytes.Split(out, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		paths = append(paths, filepath.FromSlash(string(entry[3:])))
+		// Renames and copies are followed by their original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+
+	return paths
+}
+
+// mediaType strips the parameters of a content type
+func mediaType(contentType string) string {
+	return strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+}
+
+// allowed tells whether value passes the allow and block lists
+func allowed(value string, allow, block []string, match func(string, string) bool) bool {
+	for _, pattern := range block {
+		if match(pattern, value) {
+			return false
+		}
+	}
+
+	if len(allow) == 0 {
+		return true
+	}
+
+	for _, pattern := range allow {
+		if match(pattern, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchExtension compares extensions with or without their leading dot
+func matchExtension(pattern, ext string) bool {
+	return strings.TrimPrefix(strings.ToLower(pattern), ".") == strings.TrimPrefix(ext, ".")
+}
+
+// matchType compares media types, a pattern like image/* matches a whole family
+func matchType(pattern, mediaType string) bool {
+	pattern = strings.ToLower(pattern)
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == mediaType
+}
@@ -0,0 +1,79 @@
+package gitcommand
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var allowedCases = []struct {
+	value string
+	allow []string
+	block []string
+	match func(string, string) bool
+	out   bool
+}{
+	{".exe", nil, nil, matchExtension, true},
+	{".exe", nil, []string{"exe"}, matchExtension, false},
+	{".zip", []string{".zip", "7z"}, nil, matchExtension, true},
+	{".rar", []string{".zip", "7z"}, nil, matchExtension, false},
+	{"", []string{".zip"}, nil, matchExtension, false},
+	{"image/png", []string{"image/*"}, nil, matchType, true},
+	{"image/png", []string{"image/*"}, []string{"image/png"}, matchType, false},
+	{"text/plain", []string{"application/zip"}, nil, matchType, false},
+}
+
+func TestAllowed(t *testing.T) {
+	for _, c := range allowedCases {
+		if out := allowed(c.value, c.allow, c.block, c.match); out != c.out {
+			t.Fatalf("allowed(%q, %v, %v) = %t", c.value, c.allow, c.block, out)
+		}
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	out := []byte("?? sample-files/a.bin\x00R  sample-files/new.bin\x00sample-files/old.bin\x00 M sample-files/b.txt\x00")
+	want := []string{"sample-files/a.bin", "sample-files/new.bin", "sample-files/b.txt"}
+
+	if paths := parseStatus(out); !reflect.DeepEqual(paths, want) {
+		t.Fatal(paths)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	if list := SplitList(" .exe, ,dll,"); !reflect.DeepEqual(list, []string{".exe", "dll"}) {
+		t.Fatal(list)
+	}
+}
+
+// stringSource is a source of an upload with the given content
+func stringSource(filename, content string) Source {
+	return Source{Filename: filename, Open: func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(content)), nil
+	}}
+}
+
+func TestMaxFilesWithMetadata(t *testing.T) {
+	defer testRepo(t)()
+
+	// Sidecars wait for the next push with their files, they do not count toward the limits
+	ctx := context.Background()
+	config := &Config{UploadsDir: "sample-files", Metadata: MetadataJSON, Policy: Policy{MaxFiles: 2, MaxPushSize: 2}}
+	if err := config.ExcludeTemps(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.bin", "b.bin"} {
+		if _, err := config.StoreFiles(ctx, []Source{stringSource(name, "x")}, nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	_, err := config.StoreFiles(ctx, []Source{stringSource("c.bin", "x")}, nil)
+	if policyErr, ok := err.(*PolicyError); !ok || policyErr.Status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package gitcommand
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach runs cmd in its own process group, so a Ctrl-C in the terminal does not kill it halfway
+// The server finishes its running commands when it shuts down
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -0,0 +1,12 @@
+package gitcommand
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach runs cmd in its own process group, so a Ctrl-C in the console does not kill it halfway
+// The server finishes its running commands when it shuts down
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
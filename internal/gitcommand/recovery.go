@@ -0,0 +1,385 @@
+package gitcommand
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// uploadsFile is the journal of the files stored in UploadsDir and not pushed yet, in the state directory
+	uploadsFile = "uploads.jsonl"
+
+	// RecoverAsk, RecoverResume, RecoverRollback and RecoverIgnore are the answers to an interrupted server
+	RecoverAsk      = "ask"
+	RecoverResume   = "resume"
+	RecoverRollback = "rollback"
+	RecoverIgnore   = "ignore"
+)
+
+// ValidRecover tells whether mode is a known answer to an interrupted server
+func ValidRecover(mode string) bool {
+	return mode == RecoverAsk || mode == RecoverResume || mode == RecoverRollback || mode == RecoverIgnore
+}
+
+// UploadRecord is an entry of the uploads journal, written before a file is copied and once it is stored
+type UploadRecord struct {
+	Path   string    `json:"path"`
+	Oid    string    `json:"oid,omitempty"`
+	Size   int64     `json:"size"`
+	Time   time.Time `json:"time"`
+	Stored bool      `json:"stored"`
+}
+
+// Recovery is what a server killed between an upload and a push, or during a push, left in the repository
+type Recovery struct {
+	// Jobs are the push jobs which started and never finished
+	Jobs []Job `json:"jobs"`
+	// Unpushed are the commits of push jobs on the branch which are on no branch of the remote
+	Unpushed []string `json:"unpushed"`
+	// Partial are the files whose copy into UploadsDir was interrupted
+	Partial []string `json:"partial"`
+	// Pending are the files stored by uploads and not committed yet
+	Pending []string `json:"pending"`
+	// Locks are the lock files left by a killed git command
+	Locks []string `json:"locks"`
+}
+
+// Empty tells whether there is nothing to recover
+func (r *Recovery) Empty() bool {
+	return len(r.Jobs) == 0 && len(r.Unpushed) == 0 && len(r.Partial) == 0 && len(r.Pending) == 0 && len(r.Locks) == 0
+}
+
+func (r *Recovery) String() string {
+	var lines []string
+	for _, job := range r.Jobs {
+		lines = append(lines, fmt.Sprintf("push job %s started at %s was interrupted", job.ID, job.Started.Format(time.RFC3339)))
+	}
+	if len(r.Unpushed) > 0 {
+		lines = append(lines, fmt.Sprintf("%d commits are not pushed", len(r.Unpushed)))
+	}
+	for _, path := range r.Partial {
+		lines = append(lines, fmt.Sprintf("upload of %s was interrupted", path))
+	}
+	if len(r.Pending) > 0 {
+		lines = append(lines, fmt.Sprintf("%d uploaded files are not committed", len(r.Pending)))
+	}
+	for _, lock := range r.Locks {
+		lines = append(lines, fmt.Sprintf("git left the lock %s", lock))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Recover returns what an interrupted server left in the repository
+// It must run before the server starts, when no git command works in the repository
+func (config *Config) Recover(ctx context.Context) (*Recovery, error) {
+	recovery := &Recovery{}
+
+	jobs, err := config.ReadJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.Finished.IsZero() {
+			recovery.Jobs = append(recovery.Jobs, job)
+		}
+	}
+
+	unpushed, err := config.UnpushedCommits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if recovery.Unpushed, _, err = config.ownCommits(ctx, unpushed); err != nil {
+		return nil, err
+	}
+
+	records, err := config.readUploads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := config.PendingFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if _, ok := pending[filepath.FromSlash(record.Path)]; !ok {
+			continue
+		}
+		if record.Stored {
+			recovery.Pending = append(recovery.Pending, record.Path)
+		} else {
+			recovery.Partial = append(recovery.Partial, record.Path)
+		}
+	}
+
+	locks, err := config.lockFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, lock := range locks {
+		if _, err := os.Stat(lock); err == nil {
+			recovery.Locks = append(recovery.Locks, lock)
+		}
+	}
+
+	return recovery, nil
+}
+
+// Resume removes the locks and the partial uploads, which have to be uploaded again,
+// then commits the pending files and pushes the unpushed commits in a new push job
+// It returns nil without anything to push
+func (config *Config) Resume(ctx context.Context, recovery *Recovery) (*Job, error) {
+	if err := config.clearInterrupted(ctx, recovery, recovery.Partial, "interrupted, resumed"); err != nil {
+		return nil, err
+	}
+	if err := config.forgetUploads(ctx, recovery.Partial); err != nil {
+		return nil, err
+	}
+
+	if len(recovery.Pending) == 0 && len(recovery.Unpushed) == 0 {
+		return nil, nil
+	}
+	return config.RunPush(ctx)
+}
+
+// Rollback removes the locks, discards the partial and pending uploads, and resets the branch to the remote one,
+// removing the files of the unpushed commits, the deletions and the reset are recorded in the audit log
+// Every unpushed commit of the branch must come from a push job, local changes to the files they touch are kept
+func (config *Config) Rollback(ctx context.Context, recovery *Recovery) error {
+	if len(recovery.Unpushed) > 0 {
+		unpushed, err := config.UnpushedCommits(ctx)
+		if err != nil {
+			return err
+		}
+		_, others, err := config.ownCommits(ctx, unpushed)
+		if err != nil {
+			return err
+		}
+		if len(others) > 0 {
+			return fmt.Errorf("commit %s was not made by add2git-lfs, roll back by hand", others[0])
+		}
+	}
+
+	removed := append(append([]string{}, recovery.Partial...), recovery.Pending...)
+	if err := config.clearInterrupted(ctx, recovery, removed, "interrupted, rolled back"); err != nil {
+		return err
+	}
+
+	if len(recovery.Unpushed) > 0 {
+		upstream := fmt.Sprintf("refs/remotes/%s/%s", config.Remote, config.Branch)
+		out, err := config.git(ctx, "rev-parse", "--verify", "--quiet", upstream)
+		if err != nil {
+			return fmt.Errorf("%s was never pushed to %s, there is no commit to roll back to", config.Branch, config.Remote)
+		}
+		_, err = config.git(ctx, "reset", "--keep", upstream)
+		reset := AuditEvent{Action: AuditReset, Status: AuditOK, Commit: strings.TrimSpace(string(out))}
+		if err != nil {
+			reset.Status = AuditFailed
+			reset.Error = err.Error()
+		}
+		config.audit(ctx, reset)
+		if err != nil {
+			return err
+		}
+	}
+
+	return config.forgetUploads(ctx, removed)
+}
+
+// clearInterrupted removes the locks, discards the given uploads with their sidecars,
+// and closes the interrupted jobs with the given error
+func (config *Config) clearInterrupted(ctx context.Context, recovery *Recovery, uploads []string, reason string) error {
+	for _, lock := range recovery.Locks {
+		if err := os.Remove(lock); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	var discarded []string
+	for _, path := range uploads {
+		path = filepath.FromSlash(path)
+		for _, file := range append([]string{path}, sidecars(path)...) {
+			if err := config.discardUpload(ctx, file); err != nil {
+				config.audit(ctx, AuditEvent{Action: AuditDelete, Status: AuditFailed, Files: append(discarded, filepath.ToSlash(file)), Error: err.Error()})
+				return err
+			}
+		}
+		discarded = append(discarded, filepath.ToSlash(path))
+	}
+	if len(discarded) > 0 {
+		config.audit(ctx, AuditEvent{Action: AuditDelete, Status: AuditOK, Files: discarded})
+	}
+
+	for _, job := range recovery.Jobs {
+		job.Finished = time.Now().UTC()
+		job.Error = reason
+		if err := config.recordJob(ctx, &job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// discardUpload puts a file an upload overwrote back as it is committed on HEAD,
+// and removes it from the index and the disk when HEAD has none
+func (config *Config) discardUpload(ctx context.Context, path string) error {
+	if _, err := config.git(ctx, "cat-file", "-e", "HEAD:"+filepath.ToSlash(path)); err == nil {
+		_, err := config.git(ctx, "checkout", "HEAD", "--", path)
+		return err
+	}
+
+	if _, err := config.git(ctx, "rm", "--cached", "-q", "--ignore-unmatch", "--", path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ownCommits splits commits into those made by a push job of the journal and the others
+func (config *Config) ownCommits(ctx context.Context, commits []string) ([]string, []string, error) {
+	jobs, err := config.ReadJobs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	own := make(map[string]bool)
+	for _, job := range jobs {
+		own[job.Commit] = true
+	}
+	var mine, others []string
+	for _, commit := range commits {
+		if own[commit] {
+			mine = append(mine, commit)
+		} else {
+			others = append(others, commit)
+		}
+	}
+	return mine, others, nil
+}
+
+// sidecars returns the paths of the sidecars a file may have
+func sidecars(path string) []string {
+	var paths []string
+	for _, suffix := range metadataSuffixes {
+		paths = append(paths, path+suffix)
+	}
+	return paths
+}
+
+// journalUpload appends a record to the uploads journal
+func (config *Config) journalUpload(ctx context.Context, record UploadRecord) error {
+	config.uploadsMu.Lock()
+	defer config.uploadsMu.Unlock()
+
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, uploadsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readUploads returns the last record of each file of the uploads journal
+func (config *Config) readUploads(ctx context.Context) ([]UploadRecord, error) {
+	config.uploadsMu.Lock()
+	defer config.uploadsMu.Unlock()
+
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(dir, uploadsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseUploads(file)
+}
+
+// forgetUploads rewrites the uploads journal without the given files, once pushed or removed
+func (config *Config) forgetUploads(ctx context.Context, paths []string) error {
+	records, err := config.readUploads(ctx)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+
+	config.uploadsMu.Lock()
+	defer config.uploadsMu.Unlock()
+
+	forget := make(map[string]bool)
+	for _, path := range paths {
+		forget[filepath.ToSlash(path)] = true
+	}
+
+	var kept []byte
+	for _, record := range records {
+		if forget[record.Path] {
+			continue
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		kept = append(append(kept, line...), '\n')
+	}
+
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		return err
+	}
+	journal := filepath.Join(dir, uploadsFile)
+	if err := ioutil.WriteFile(journal+".tmp", kept, 0644); err != nil {
+		return err
+	}
+	return os.Rename(journal+".tmp", journal)
+}
+
+// parseUploads returns the last record of each file of an uploads journal, in the order they were first uploaded
+func parseUploads(r io.Reader) ([]UploadRecord, error) {
+	var records []UploadRecord
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var record UploadRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Path == "" {
+			continue
+		}
+		if i, ok := index[record.Path]; ok {
+			records[i] = record
+			continue
+		}
+		index[record.Path] = len(records)
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
@@ -0,0 +1,192 @@
+package gitcommand
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseUploads(t *testing.T) {
+	journal := `{"path":"sample-files/a.txt","size":5,"time":"2019-09-07T12:00:00Z","stored":false}
+{"path":"sample-files/b.txt","size":7,"time":"2019-09-07T12:00:00Z","stored":false}
+{"path":"sample-files/a.txt","size":5,"time":"2019-09-07T12:00:00Z","stored":true}
+`
+
+	records, err := parseUploads(strings.NewReader(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !records[0].Stored || records[1].Stored {
+		t.Fatal(records)
+	}
+}
+
+func TestRecoveryEmpty(t *testing.T) {
+	if recovery := (&Recovery{}); !recovery.Empty() {
+		t.Fatal("a recovery without anything should be empty")
+	}
+
+	recovery := &Recovery{Partial: []string{"sample-files/b.txt"}}
+	if recovery.Empty() || recovery.String() != "upload of sample-files/b.txt was interrupted" {
+		t.Fatal(recovery)
+	}
+}
+
+func TestRollbackKeepsCommittedFiles(t *testing.T) {
+	defer testRepo(t)()
+	testCommit(t, "sample-files/hello.txt", "hello")
+	testGit(t, "push", "-q", "origin", "master")
+
+	ctx := context.Background()
+	config := &Config{Branch: "master", Remote: "origin", UploadsDir: "sample-files"}
+
+	// An upload overwrote a committed file and added a new one with its sidecar, the push never ran
+	for path, content := range map[string]string{
+		"sample-files/hello.txt":           "overwritten",
+		"sample-files/new.txt":             "new",
+		"sample-files/new.txt.meta.json":   "{}",
+		"sample-files/hello.txt.meta.json": "{}",
+	} {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testGit(t, "add", "sample-files/new.txt")
+	for _, path := range []string{"sample-files/hello.txt", "sample-files/new.txt"} {
+		if err := config.journalUpload(ctx, UploadRecord{Path: path, Time: time.Now(), Stored: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recovery, err := config.Recover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sample-files/hello.txt", "sample-files/new.txt"}; !reflect.DeepEqual(recovery.Pending, want) {
+		t.Fatalf("got %v, want %v", recovery.Pending, want)
+	}
+
+	path, err := config.DefaultAuditLog(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Audit, err = OpenAuditLog(path, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer config.Audit.Close()
+
+	if err := config.Rollback(ctx, recovery); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := config.Audit.Query(AuditFilter{})
+	if err != nil || len(events) != 1 || events[0].Action != AuditDelete || !reflect.DeepEqual(events[0].Files, recovery.Pending) {
+		t.Fatalf("got %+v, %v", events, err)
+	}
+
+	if status := testGit(t, "status", "--porcelain", "--untracked-files=all"); status != "" {
+		t.Fatalf("the worktree should be clean, got\n%s", status)
+	}
+	if content, err := ioutil.ReadFile("sample-files/hello.txt"); err != nil || string(content) != "hello" {
+		t.Fatalf("got %q, %v", content, err)
+	}
+	if _, err := os.Stat("sample-files/new.txt"); !os.IsNotExist(err) {
+		t.Fatal("the new upload should be removed")
+	}
+	if records, err := config.readUploads(ctx); err != nil || len(records) != 0 {
+		t.Fatalf("got %v, %v", records, err)
+	}
+}
+
+func TestRecoverOwnCommits(t *testing.T) {
+	defer testRepo(t)()
+
+	ctx := context.Background()
+	config := &Config{Branch: "master", Remote: "origin", UploadsDir: "sample-files"}
+
+	// A commit made by hand is none of the server's business
+	testCommit(t, "notes.txt", "by hand")
+	recovery, err := config.Recover(ctx)
+	if err != nil || !recovery.Empty() {
+		t.Fatalf("got %+v, %v", recovery, err)
+	}
+	testGit(t, "push", "-q", "origin", "master")
+
+	// A push job interrupted after its commit left it unpushed
+	testCommit(t, "sample-files/hello.txt", "hello")
+	commit := testGit(t, "rev-parse", "HEAD")
+	if err := config.recordJob(ctx, &Job{ID: newJobID(), Started: time.Now().UTC(), Commit: commit}); err != nil {
+		t.Fatal(err)
+	}
+	recovery, err = config.Recover(ctx)
+	if err != nil || len(recovery.Jobs) != 1 || !reflect.DeepEqual(recovery.Unpushed, []string{commit}) {
+		t.Fatalf("got %+v, %v", recovery, err)
+	}
+
+	path, err := config.DefaultAuditLog(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Audit, err = OpenAuditLog(path, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer config.Audit.Close()
+
+	if err := config.Rollback(ctx, recovery); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("sample-files/hello.txt"); !os.IsNotExist(err) {
+		t.Fatal("the commit should be rolled back")
+	}
+	upstream := testGit(t, "rev-parse", "origin/master")
+	events, err := config.Audit.Query(AuditFilter{Action: AuditReset})
+	if err != nil || len(events) != 1 || events[0].Status != AuditOK || events[0].Commit != upstream {
+		t.Fatalf("got %+v, %v", events, err)
+	}
+	if recovery, err := config.Recover(ctx); err != nil || !recovery.Empty() {
+		t.Fatalf("got %+v, %v", recovery, err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	defer testRepo(t)()
+	testCommit(t, attributesFile, "")
+	testGit(t, "push", "-q", "origin", "master")
+
+	ctx := context.Background()
+	config := &Config{Branch: "master", Remote: "origin", UploadsDir: "sample-files"}
+
+	// One upload was stored, the other one was killed while its file was copied
+	os.Mkdir("sample-files", os.ModePerm)
+	for path, stored := range map[string]bool{"sample-files/stored.txt": true, "sample-files/partial.txt": false} {
+		if err := ioutil.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := config.journalUpload(ctx, UploadRecord{Path: path, Time: time.Now(), Stored: stored}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recovery, err := config.Recover(ctx)
+	if err != nil || len(recovery.Pending) != 1 || len(recovery.Partial) != 1 {
+		t.Fatalf("got %+v, %v", recovery, err)
+	}
+
+	job, err := config.Resume(ctx, recovery)
+	if err != nil || job == nil || !job.Pushed || !reflect.DeepEqual(job.Files, []string{"sample-files/stored.txt"}) {
+		t.Fatalf("got %+v, %v", job, err)
+	}
+	if files := testGit(t, "ls-tree", "-r", "--name-only", "origin/master", "sample-files"); files != "sample-files/stored.txt" {
+		t.Fatalf("got %s", files)
+	}
+	if _, err := os.Stat("sample-files/partial.txt"); !os.IsNotExist(err) {
+		t.Fatal("the partial upload should be removed")
+	}
+	if recovery, err := config.Recover(ctx); err != nil || !recovery.Empty() {
+		t.Fatalf("got %+v, %v", recovery, err)
+	}
+}
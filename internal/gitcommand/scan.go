@@ -0,0 +1,332 @@
+package gitcommand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Detection modes of a scanner command, given as a prefix of its command line
+const (
+	// DetectExitCode detects a file when the command exits with 1, as clamscan does
+	DetectExitCode = "exit"
+	// DetectOutput detects a file when the command prints something and exits with 0, as yara does
+	DetectOutput = "output"
+)
+
+// Scan actions on files a scanner detects
+const (
+	ScanBlock      = "block"
+	ScanTag        = "tag"
+	ScanQuarantine = "quarantine"
+)
+
+const (
+	// detectedTag is added to the tags of files a scanner detects with the tag action
+	detectedTag = "detected"
+
+	// quarantineDir is the directory of quarantined files in the state directory
+	quarantineDir = "quarantine"
+
+	// maxScanDetail is the size of the scanner output kept in a ScanResult
+	maxScanDetail = 1024
+
+	// scanPlaceholder is replaced by the path of the scanned file in the arguments of a scanner command
+	scanPlaceholder = "{}"
+)
+
+// Scanner checks a file before it is committed
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, path string) (ScanResult, error)
+}
+
+// ScanResult is what a scanner found in a file, recorded in its sidecar
+type ScanResult struct {
+	Scanner  string `json:"scanner" yaml:"scanner"`
+	Detected bool   `json:"detected" yaml:"detected"`
+	Detail   string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// ScanError refuses an upload with a file a scanner detects
+type ScanError struct {
+	File    string       `json:"file"`
+	Results []ScanResult `json:"scan"`
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("%s: detected by %s", e.File, strings.Join(detectedBy(e.Results), ", "))
+}
+
+// Quarantined is a detected file kept out of the repository in the state directory
+type Quarantined struct {
+	File    string       `json:"file"`
+	Path    string       `json:"path"`
+	Results []ScanResult `json:"scan"`
+}
+
+// CommandScanner runs a command on each file, like clamscan --infected --no-summary or yara -w rules.yar,
+// through the Config which made it, so the command is logged and waited for on shutdown
+// With DetectExitCode the file is clean when the command exits with 0 and detected with 1, with DetectOutput
+// it is detected when the command prints something, other exit codes are errors
+type CommandScanner struct {
+	Args   []string
+	Detect string
+
+	config *Config
+}
+
+// HTTPScanner posts each file to a local service, which answers 204 for a clean file
+// or 200 with a JSON {"detected": bool, "detail": string}
+type HTTPScanner struct {
+	URL    string
+	Client *http.Client
+}
+
+// ValidScanAction tells whether action is a known scan action
+func ValidScanAction(action string) bool {
+	return action == ScanBlock || action == ScanTag || action == ScanQuarantine
+}
+
+// ParseScanner returns the scanner of an http(s) URL or of a command line, where {} stands for the file,
+// prefixed with its detection mode, exit: (the default) or output:
+func (config *Config) ParseScanner(spec string) (Scanner, error) {
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		if _, err := url.Parse(spec); err != nil {
+			return nil, err
+		}
+		return &HTTPScanner{URL: spec, Client: &http.Client{}}, nil
+	}
+
+	detect := DetectExitCode
+	for _, mode := range []string{DetectExitCode, DetectOutput} {
+		if strings.HasPrefix(spec, mode+":") {
+			detect = mode
+			spec = strings.TrimPrefix(spec, mode+":")
+		}
+	}
+
+	args := strings.Fields(spec)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty scanner command")
+	}
+	return &CommandScanner{Args: args, Detect: detect, config: config}, nil
+}
+
+// Name returns the name of the command
+func (s *CommandScanner) Name() string {
+	return filepath.Base(s.Args[0])
+}
+
+// Scan runs the command on the file at path
+func (s *CommandScanner) Scan(ctx context.Context, path string) (ScanResult, error) {
+	result := ScanResult{Scanner: s.Name()}
+
+	var args []string
+	placed := false
+	for _, arg := range s.Args[1:] {
+		if strings.Contains(arg, scanPlaceholder) {
+			arg = strings.Replace(arg, scanPlaceholder, path, -1)
+			placed = true
+		}
+		args = append(args, arg)
+	}
+	if !placed {
+		args = append(args, path)
+	}
+
+	// The command is killed when the scan times out, without waiting for children keeping its output open
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Args[0], args...)
+	cmd.Stdout = &stdout
+	cmd.WaitDelay = time.Second
+	_, err := s.config.run(ctx, cmd)
+
+	output := strings.TrimSpace(stdout.String())
+	var exitErr *exec.ExitError
+	if s.Detect != DetectOutput && errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		result.Detected = true
+		result.Detail = output
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("%s: %s", result.Scanner, strings.TrimSpace(err.Error()))
+	}
+
+	if s.Detect == DetectOutput && output != "" {
+		result.Detected = true
+		result.Detail = output
+	}
+	return result, nil
+}
+
+// Name returns the host of the service
+func (s *HTTPScanner) Name() string {
+	if u, err := url.Parse(s.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return s.URL
+}
+
+// Scan posts the file at path to the service
+func (s *HTTPScanner) Scan(ctx context.Context, path string) (ScanResult, error) {
+	result := ScanResult{Scanner: s.Name()}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, file)
+	if err != nil {
+		return result, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("%s: %s", result.Scanner, err.Error())
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return result, nil
+	case http.StatusOK:
+		var answer struct {
+			Detected bool   `json:"detected"`
+			Detail   string `json:"detail"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+			return result, fmt.Errorf("%s: %s", result.Scanner, err.Error())
+		}
+		result.Detected = answer.Detected
+		result.Detail = answer.Detail
+		return result, nil
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxScanDetail))
+	return result, fmt.Errorf("%s: %s %s", result.Scanner, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// scanUploads runs the Scanners on each staged file and applies the ScanAction to the detected ones
+// A file which cannot be scanned refuses the upload, the quarantined files are removed from uploads
+func (config *Config) scanUploads(ctx context.Context, uploads []upload) ([]upload, []Quarantined, error) {
+	if len(config.Scanners) == 0 {
+		return uploads, nil, nil
+	}
+
+	var kept []upload
+	var quarantined []Quarantined
+	for i := range uploads {
+		file := &uploads[i]
+
+		detected, err := config.scanUpload(ctx, file)
+		if err != nil {
+			return uploads, nil, err
+		}
+		if !detected || config.ScanAction == ScanTag {
+			kept = append(kept, *file)
+			continue
+		}
+		if config.ScanAction != ScanQuarantine {
+			return uploads, nil, &ScanError{file.Filename, file.Scan}
+		}
+
+		q, err := config.quarantine(ctx, file)
+		if err != nil {
+			return uploads, nil, err
+		}
+		quarantined = append(quarantined, q)
+	}
+
+	return kept, quarantined, nil
+}
+
+// scanUpload runs the Scanners on a staged file, recording their results in it
+func (config *Config) scanUpload(ctx context.Context, file *upload) (bool, error) {
+	timeout := config.ScanTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	detected := false
+	for _, scanner := range config.Scanners {
+		scanCtx, cancel := context.WithTimeout(ctx, timeout)
+		result, err := scanner.Scan(scanCtx, file.Temp)
+		cancel()
+		if err != nil {
+			return false, fmt.Errorf("Error when scanning %s with %s", file.Filename, err.Error())
+		}
+
+		// Scanners print the path they were given, which is the temporary file
+		result.Detail = strings.Replace(result.Detail, file.Temp, file.Filename, -1)
+		if len(result.Detail) > maxScanDetail {
+			result.Detail = result.Detail[:maxScanDetail]
+		}
+
+		config.loggerFor(ctx).Info("scan", "file", file.Filename, "scanner", result.Scanner, "detected", result.Detected, "detail", result.Detail)
+		file.Scan = append(file.Scan, result)
+		detected = detected || result.Detected
+	}
+
+	return detected, nil
+}
+
+// quarantine moves a staged file to the quarantine directory as <sha256>, with a <sha256>.json describing it
+func (config *Config) quarantine(ctx context.Context, file *upload) (Quarantined, error) {
+	q := Quarantined{File: file.Filename, Results: file.Scan}
+
+	state, err := config.StateDir(ctx)
+	if err != nil {
+		return q, err
+	}
+	dir := filepath.Join(state, quarantineDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return q, err
+	}
+
+	q.Path = filepath.Join(dir, file.Oid)
+	meta := Metadata{Filename: file.Filename, Sha256: file.Oid, Size: file.Size, MimeType: file.Type, Uploaded: time.Now().UTC(), Scan: file.Scan}
+	content, err := encodeMetadata(meta, MetadataJSON)
+	if err != nil {
+		return q, err
+	}
+	err = writeAtomic(q.Path+".json", func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+	if err != nil {
+		return q, err
+	}
+
+	if err := os.Rename(file.Temp, q.Path); err != nil {
+		return q, err
+	}
+	file.Temp = ""
+	return q, nil
+}
+
+// detectedBy returns the names of the scanners which detected a file
+func detectedBy(results []ScanResult) []string {
+	var names []string
+	for _, result := range results {
+		if result.Detected {
+			names = append(names, result.Scanner)
+		}
+	}
+	return names
+}
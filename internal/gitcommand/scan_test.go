@@ -0,0 +1,207 @@
+package gitcommand
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScanner(t *testing.T) {
+	cases := []struct {
+		spec  string
+		name  string
+		valid bool
+	}{
+		{"clamscan --infected --no-summary", "clamscan", true},
+		{"output:/usr/bin/yara -w rules.yar {}", "yara", true},
+		{"http://127.0.0.1:1344/scan", "127.0.0.1:1344", true},
+		{"  ", "", false},
+	}
+
+	for _, c := range cases {
+		scanner, err := (&Config{}).ParseScanner(c.spec)
+		if (err == nil) != c.valid || (err == nil && scanner.Name() != c.name) {
+			t.Fatalf("%q: got %v, %v", c.spec, scanner, err)
+		}
+	}
+}
+
+func TestCommandScanner(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+
+	cases := []struct {
+		detect   string
+		script   string
+		detected bool
+		detail   string
+		valid    bool
+	}{
+		{DetectExitCode, "exit 0", false, "", true},
+		{DetectExitCode, `echo "$1: OK"`, false, "", true},
+		{DetectExitCode, `echo "Eicar $1"; exit 1`, true, "Eicar sample.exe", true},
+		{DetectExitCode, "exit 1", true, "", true},
+		{DetectExitCode, "echo broken >&2; exit 2", false, "", false},
+		{DetectOutput, "exit 0", false, "", true},
+		{DetectOutput, `echo "Eicar $1"`, true, "Eicar sample.exe", true},
+		{DetectOutput, "echo broken >&2; exit 1", false, "", false},
+	}
+
+	config := &Config{}
+	for _, c := range cases {
+		scanner := &CommandScanner{Args: []string{"sh", "-c", c.script, "scanner"}, Detect: c.detect, config: config}
+		result, err := scanner.Scan(context.Background(), "sample.exe")
+		if (err == nil) != c.valid || result.Detected != c.detected || result.Detail != c.detail {
+			t.Fatalf("%s %q: got %+v, %v", c.detect, c.script, result, err)
+		}
+	}
+
+	// yara prints the rules matching a file and exits with 0
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	yara := filepath.Join(dir, "yara")
+	if err := ioutil.WriteFile(yara, []byte("#!/bin/sh\necho \"eicar_rule $3\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for spec, detected := range map[string]bool{"output:" + yara + " -w rules.yar {}": true, yara + " -w rules.yar {}": false} {
+		scanner, err := config.ParseScanner(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := scanner.Scan(context.Background(), "sample.exe")
+		if err != nil || result.Detected != detected || (detected && result.Detail != "eicar_rule sample.exe") {
+			t.Fatalf("%s: got %+v, %v", spec, result, err)
+		}
+	}
+
+	scanner := &CommandScanner{Args: []string{"sh", "-c", `echo "$0"; exit 1`, "--file={}"}, config: config}
+	if result, err := scanner.Scan(context.Background(), "sample.exe"); err != nil || result.Detail != "--file=sample.exe" {
+		t.Fatalf("got %+v, %v", result, err)
+	}
+
+	// A scanner which does not finish in time is killed
+	scanner = &CommandScanner{Args: []string{"sh", "-c", "exec sleep 10"}, config: config}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := scanner.Scan(ctx, "sample.exe"); err == nil {
+		t.Fatal("the scan should time out")
+	}
+}
+
+func TestHTTPScanner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch string(body) {
+		case "clean":
+			w.WriteHeader(http.StatusNoContent)
+		case "eicar":
+			w.Write([]byte(`{"detected": true, "detail": "Eicar-Signature"}`))
+		default:
+			http.Error(w, "cannot scan", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		content  string
+		detected bool
+		valid    bool
+	}{
+		{"clean", false, true},
+		{"eicar", true, true},
+		{"broken", false, false},
+	}
+
+	scanner := &HTTPScanner{URL: server.URL, Client: server.Client()}
+	for _, c := range cases {
+		path := filepath.Join(dir, c.content)
+		if err := ioutil.WriteFile(path, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := scanner.Scan(context.Background(), path)
+		if (err == nil) != c.valid || result.Detected != c.detected {
+			t.Fatalf("%s: got %+v, %v", c.content, result, err)
+		}
+	}
+}
+
+// fakeScanner detects files containing a word
+type fakeScanner struct {
+	word string
+}
+
+func (s fakeScanner) Name() string {
+	return "fake"
+}
+
+func (s fakeScanner) Scan(ctx context.Context, path string) (ScanResult, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	if strings.Contains(string(content), s.word) {
+		return ScanResult{Scanner: "fake", Detected: true, Detail: path + ": " + s.word}, nil
+	}
+	return ScanResult{Scanner: "fake"}, nil
+}
+
+func TestScanUploads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		action  string
+		content string
+		kept    int
+		refused bool
+	}{
+		{ScanBlock, "hello", 1, false},
+		{ScanBlock, "eicar", 0, true},
+		{ScanTag, "eicar", 1, false},
+	}
+
+	for _, c := range cases {
+		config := &Config{UploadsDir: dir, Scanners: []Scanner{fakeScanner{"eicar"}}, ScanAction: c.action}
+		file, err := config.stage("sample.exe", strings.NewReader(c.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		uploads, _, err := config.scanUploads(context.Background(), []upload{file})
+		removeStaged(uploads)
+		if _, ok := err.(*ScanError); ok != c.refused {
+			t.Fatalf("%s %s: got %v", c.action, c.content, err)
+		}
+		if c.refused {
+			continue
+		}
+		if err != nil || len(uploads) != c.kept || len(uploads[0].Scan) != 1 {
+			t.Fatalf("%s %s: got %v, %v", c.action, c.content, uploads, err)
+		}
+
+		meta := config.uploadMetadata(uploads[0], nil, time.Time{})
+		if uploads[0].Scan[0].Detected && (!containsTag(meta.Tags, detectedTag) || meta.Scan[0].Detail != "sample.exe: eicar") {
+			t.Fatalf("%s %s: got %+v", c.action, c.content, meta)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package gitcommand
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Stop makes the server refuse new uploads and pushes and fail its readiness check while it shuts down
+func (config *Config) Stop() {
+	atomic.StoreInt32(&config.stopping, 1)
+}
+
+// Stopping tells whether the server shuts down
+func (config *Config) Stopping() bool {
+	return atomic.LoadInt32(&config.stopping) == 1
+}
+
+// RefuseWhenStopping is an echo middleware refusing mutating requests once the server shuts down
+// Requests already running, such as pushes waiting in queue, are drained by the server shutdown
+func (config *Config) RefuseWhenStopping(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if config.Stopping() && !safeMethod(c.Request().Method) {
+			c.Response().Header().Set("Retry-After", "60")
+			return c.JSON(http.StatusServiceUnavailable, echo.Map{"error": "the server is shutting down"})
+		}
+		return next(c)
+	}
+}
+
+// CheckStopping fails once the server shuts down, so load balancers stop sending requests
+func (config *Config) CheckStopping(ctx context.Context) Check {
+	if config.Stopping() {
+		return Check{Name: "shutdown", Detail: "the server is shutting down"}
+	}
+	return Check{Name: "shutdown", OK: true, Detail: "the server is running"}
+}
+
+// WaitCommands waits for the running commands, a push cannot be left with half of its steps done
+func (config *Config) WaitCommands(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for atomic.LoadInt32(&config.running) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// lockFiles returns the paths of the lock files git takes for add, commit and push
+func (config *Config) lockFiles(ctx context.Context) ([]string, error) {
+	out, err := config.git(ctx, "rev-parse", "--git-dir", "--git-common-dir")
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := strings.Fields(string(out))
+	if len(dirs) != 2 {
+		return nil, nil
+	}
+	gitDir, commonDir := dirs[0], dirs[1]
+
+	return []string{
+		filepath.Join(gitDir, "index.lock"),
+		filepath.Join(gitDir, "HEAD.lock"),
+		filepath.Join(gitDir, "ORIG_HEAD.lock"),
+		filepath.Join(commonDir, "refs", "heads", filepath.FromSlash(config.Branch)+".lock"),
+		filepath.Join(commonDir, "refs", "remotes", config.Remote, filepath.FromSlash(config.Branch)+".lock"),
+		filepath.Join(commonDir, "packed-refs.lock"),
+	}, nil
+}
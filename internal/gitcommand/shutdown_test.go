@@ -0,0 +1,58 @@
+package gitcommand
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+func TestRefuseWhenStopping(t *testing.T) {
+	config := &Config{}
+	handler := config.RefuseWhenStopping(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	cases := []struct {
+		stop   bool
+		method string
+		status int
+	}{
+		{false, http.MethodPost, http.StatusOK},
+		{true, http.MethodGet, http.StatusOK},
+		{true, http.MethodPost, http.StatusServiceUnavailable},
+	}
+
+	for _, c := range cases {
+		if c.stop {
+			config.Stop()
+		}
+		rec := httptest.NewRecorder()
+		if err := handler(echo.New().NewContext(httptest.NewRequest(c.method, "/upload", nil), rec)); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != c.status {
+			t.Fatalf("%s: got %d, want %d", c.method, rec.Code, c.status)
+		}
+	}
+}
+
+func TestWaitCommands(t *testing.T) {
+	config := &Config{}
+	atomic.AddInt32(&config.running, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := config.WaitCommands(ctx); err == nil {
+		t.Fatal("a running command should be waited for")
+	}
+
+	atomic.AddInt32(&config.running, -1)
+	if err := config.WaitCommands(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
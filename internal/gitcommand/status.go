@@ -0,0 +1,86 @@
+package gitcommand
+
+import (
+	"bytes"
+	"context"
+	"sort"
+)
+
+// Status is the state of the folder and the branch between two pushes
+type Status struct {
+	Branch  string        `json:"branch"`
+	Remote  string        `json:"remote"`
+	Folder  string        `json:"folder"`
+	Pending []PendingFile `json:"pending"`
+	// Unpushed are the commits of the branch that are on no branch of the remote
+	Unpushed []string `json:"unpushed"`
+	// Jobs are the last push jobs, newest first
+	Jobs []Job `json:"jobs"`
+}
+
+// PendingFile is a file of the folder which is not committed yet
+type PendingFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Status returns the pending files, the unpushed commits and the last push jobs
+func (config *Config) Status(ctx context.Context, jobs int) (*Status, error) {
+	status := &Status{Branch: config.Branch, Remote: config.Remote, Folder: config.UploadsDir, Pending: []PendingFile{}}
+
+	pending, err := config.PendingFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for path, size := range pending {
+		status.Pending = append(status.Pending, PendingFile{path, size})
+	}
+	sort.Slice(status.Pending, func(i, j int) bool {
+		return status.Pending[i].Path < status.Pending[j].Path
+	})
+
+	if status.Unpushed, err = config.UnpushedCommits(ctx); err != nil {
+		return nil, err
+	}
+
+	all, err := config.ReadJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.Jobs = lastJobs(all, jobs)
+
+	return status, nil
+}
+
+// UnpushedCommits returns the commits of the branch which are on no branch of the remote, newest first
+// A branch without commits has nothing to push
+func (config *Config) UnpushedCommits(ctx context.Context) ([]string, error) {
+	ref := "refs/heads/" + config.Branch
+	if _, err := config.git(ctx, "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return []string{}, nil
+	}
+
+	out, err := config.git(ctx, "rev-list", ref, "--not", "--remotes="+config.Remote)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []string{}
+	for _, line := range bytes.Fields(out) {
+		commits = append(commits, string(line))
+	}
+	return commits, nil
+}
+
+// lastJobs returns the n last jobs of the journal, newest first
+func lastJobs(jobs []Job, n int) []Job {
+	if n > len(jobs) || n < 0 {
+		n = len(jobs)
+	}
+
+	last := make([]Job, 0, n)
+	for i := len(jobs) - 1; i >= len(jobs)-n; i-- {
+		last = append(last, jobs[i])
+	}
+	return last
+}
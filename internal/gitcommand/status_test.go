@@ -0,0 +1,30 @@
+package gitcommand
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLastJobs(t *testing.T) {
+	jobs := []Job{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	cases := []struct {
+		n   int
+		ids []string
+	}{
+		{0, []string{}},
+		{2, []string{"3", "2"}},
+		{5, []string{"3", "2", "1"}},
+		{-1, []string{"3", "2", "1"}},
+	}
+
+	for _, c := range cases {
+		ids := []string{}
+		for _, job := range lastJobs(jobs, c.n) {
+			ids = append(ids, job.ID)
+		}
+		if !reflect.DeepEqual(ids, c.ids) {
+			t.Fatalf("%d: got %v, want %v", c.n, ids, c.ids)
+		}
+	}
+}
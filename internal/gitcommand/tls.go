@@ -0,0 +1,166 @@
+package gitcommand
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// certificateFile and keyFile are the self-signed certificate and its key in the state directory
+	certificateFile = "tls-cert.pem"
+	keyFile         = "tls-key.pem"
+
+	// certificateLifetime is the validity of a self-signed certificate, it is renewed once expired
+	certificateLifetime = 365 * 24 * time.Hour
+)
+
+// SelfSignedCertificate returns the paths of the self-signed certificate and key kept in the state directory
+// They are generated for hosts on first run, and again when the certificate has expired or does not cover hosts
+func (config *Config) SelfSignedCertificate(ctx context.Context, hosts []string) (string, string, error) {
+	dir, err := config.StateDir(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	certPath := filepath.Join(dir, certificateFile)
+	keyPath := filepath.Join(dir, keyFile)
+	if validCertificate(certPath, keyPath, hosts, time.Now()) {
+		return certPath, keyPath, nil
+	}
+
+	config.logger().Info("generating a self-signed certificate", "cert", certPath, "hosts", hosts)
+	return certPath, keyPath, GenerateCertificate(certPath, keyPath, hosts, certificateLifetime)
+}
+
+// validCertificate tells whether the certificate and key exist, match, are valid at now and cover hosts
+func validCertificate(certPath, keyPath string, hosts []string, now time.Time) bool {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return false
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil || now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return false
+	}
+
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateCertificate writes a self-signed ECDSA certificate valid for hosts, names or IP addresses, and its key
+func GenerateCertificate(certPath, keyPath string, hosts []string, lifetime time.Duration) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"add2git-lfs"}, CommonName: "add2git-lfs self-signed"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(lifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := writePEM(keyPath, "EC PRIVATE KEY", keyDer, 0600); err != nil {
+		return err
+	}
+	return writePEM(certPath, "CERTIFICATE", der, 0644)
+}
+
+// writePEM writes a PEM block to path with the given permissions
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := pem.Encode(file, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LocalHosts returns the names and addresses of the server listening on bind, the self-signed certificate
+// covers them and they are the default AllowedHosts
+// Loopback names are always included, with the host name and the interface addresses when listening on every interface
+func LocalHosts(bind string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+
+	ip := net.ParseIP(bind)
+	switch {
+	case bind == "" || (ip != nil && ip.IsUnspecified()):
+		if name, err := os.Hostname(); err == nil {
+			hosts = append(hosts, name)
+		}
+		addrs, _ := net.InterfaceAddrs()
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && !ipnet.IP.IsLinkLocalUnicast() {
+				hosts = append(hosts, ipnet.IP.String())
+			}
+		}
+	case ip == nil || !ip.IsLoopback():
+		hosts = append(hosts, bind)
+	}
+
+	return hosts
+}
+
+// RedirectHTTPS returns a handler redirecting requests to the same host and path over HTTPS on port
+// The redirect is permanent and keeps the method, so an upload is not replayed as a GET
+func RedirectHTTPS(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		target := "https://" + net.JoinHostPort(host, strconv.Itoa(port)) + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,77 @@
+package gitcommand
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certPath := filepath.Join(dir, certificateFile)
+	keyPath := filepath.Join(dir, keyFile)
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+
+	if validCertificate(certPath, keyPath, hosts, time.Now()) {
+		t.Fatal("a missing certificate should not be valid")
+	}
+
+	if err := GenerateCertificate(certPath, keyPath, hosts, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if !validCertificate(certPath, keyPath, hosts, time.Now()) {
+		t.Fatal("the certificate should be valid")
+	}
+	if validCertificate(certPath, keyPath, hosts, time.Now().Add(2*time.Hour)) {
+		t.Fatal("an expired certificate should not be valid")
+	}
+	if validCertificate(certPath, keyPath, []string{"example.com"}, time.Now()) {
+		t.Fatal("the certificate should not cover another host")
+	}
+
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("the key should only be readable by its owner: %v", info.Mode())
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	cases := []struct {
+		host   string
+		target string
+	}{
+		{"127.0.0.1:8080", "https://127.0.0.1:12358/files?ref=dev"},
+		{"localhost", "https://localhost:12358/files?ref=dev"},
+		{"[::1]:8080", "https://[::1]:12358/files?ref=dev"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/files?ref=dev", nil)
+		req.Host = c.host
+		rec := httptest.NewRecorder()
+
+		RedirectHTTPS(12358).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != c.target {
+			t.Fatalf("%s: got %d %s, want %s", c.host, rec.Code, rec.Header().Get("Location"), c.target)
+		}
+	}
+}
+
+func TestLocalHosts(t *testing.T) {
+	if hosts := LocalHosts("127.0.0.1"); len(hosts) != 3 {
+		t.Fatal(hosts)
+	}
+	if hosts := LocalHosts("192.168.1.10"); hosts[len(hosts)-1] != "192.168.1.10" {
+		t.Fatal(hosts)
+	}
+}
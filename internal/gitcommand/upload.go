@@ -0,0 +1,493 @@
+package gitcommand
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// tempSuffix ends the temporary files of uploads, tempPattern keeps them out of git
+	tempSuffix  = ".add2git-lfs-tmp"
+	tempPattern = "*" + tempSuffix
+
+	// maxFieldsSize is the size of the fields of an upload form kept in memory
+	maxFieldsSize = 1 << 20
+)
+
+// Source is a file to store in UploadsDir from the command line
+type Source struct {
+	Filename string
+	Size     int64
+	Open     func() (io.ReadCloser, error)
+
+	// Sha256 is the checksum the client expects, if any
+	Sha256 string
+}
+
+// ChecksumError is returned when an uploaded file does not have the SHA-256 its client expects
+type ChecksumError struct {
+	File     string
+	Expected string
+	Sha256   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s: SHA-256 is %s, the client expects %s", e.File, e.Sha256, e.Expected)
+}
+
+// ExpectedChecksums returns the SHA-256 a client expects for n files, in the order of the files, or nil without any
+// They come from the sha256 form field, once per file, or from a comma-separated header
+func ExpectedChecksums(header string, values []string, n int) ([]string, error) {
+	sums := values
+	if len(sums) == 0 {
+		sums = SplitList(header)
+	}
+	if len(sums) == 0 {
+		return nil, nil
+	}
+
+	if len(sums) != n {
+		return nil, fmt.Errorf("%d checksums for %d files", len(sums), n)
+	}
+	for i, sum := range sums {
+		sums[i] = strings.ToLower(strings.TrimSpace(sum))
+		if _, err := hex.DecodeString(sums[i]); err != nil || len(sums[i]) != sha256.Size*2 {
+			return nil, fmt.Errorf("%q is not a SHA-256", sum)
+		}
+	}
+	return sums, nil
+}
+
+// FormError is returned when an upload form cannot be read
+type FormError struct {
+	Err error
+}
+
+func (e *FormError) Error() string {
+	return fmt.Sprintf("Error when parsing files %s", e.Err.Error())
+}
+
+// PathSources returns the sources of local files
+func PathSources(paths []string) ([]Source, error) {
+	sources := make([]Source, len(paths))
+
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", path)
+		}
+
+		path := path
+		sources[i] = Source{Filename: filepath.Base(path), Size: info.Size(), Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		}}
+	}
+
+	return sources, nil
+}
+
+// StoreFiles stores sources in UploadsDir like StoreMultipart, values are the fields of an upload form
+func (config *Config) StoreFiles(ctx context.Context, sources []Source, values map[string][]string) (UploadResult, error) {
+	return config.store(ctx, func() ([]upload, map[string][]string, error) {
+		quota, err := config.newPushQuota(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var uploads []upload
+		for _, source := range sources {
+			file, err := config.stageSource(source, quota)
+			if err != nil {
+				return uploads, nil, err
+			}
+			uploads = append(uploads, file)
+		}
+		return uploads, values, nil
+	})
+}
+
+// stageSource stages the file of a source, counting it in quota
+func (config *Config) stageSource(source Source, quota *pushQuota) (upload, error) {
+	src, err := source.Open()
+	if err != nil {
+		return upload{}, err
+	}
+	defer src.Close()
+
+	r, err := quota.add(src)
+	if err != nil {
+		return upload{}, err
+	}
+	file, err := config.stage(source.Filename, r)
+	if err != nil {
+		return file, err
+	}
+	file.Sha256 = source.Sha256
+	quota.staged(file)
+	return file, nil
+}
+
+// StoreMultipart streams the files of an upload form into UploadsDir, hashing them on the way,
+// header is the X-Content-Sha256 header of the request
+// Fields are kept in memory up to maxFieldsSize, files are only written once, to a temporary file renamed when accepted
+// Reading stops as soon as the files, with the pending ones, are over MaxFiles or MaxPushSize
+func (config *Config) StoreMultipart(ctx context.Context, reader *multipart.Reader, header string) (UploadResult, error) {
+	return config.store(ctx, func() ([]upload, map[string][]string, error) {
+		quota, err := config.newPushQuota(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var uploads []upload
+		values := make(map[string][]string)
+		remaining := int64(maxFieldsSize)
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return uploads, nil, &FormError{err}
+			}
+
+			// A part is only closed once read, closing it reads it to the end
+			if part.FileName() == "" {
+				value, err := ioutil.ReadAll(io.LimitReader(part, remaining+1))
+				if err != nil {
+					return uploads, nil, &FormError{err}
+				}
+				if remaining -= int64(len(value)); remaining < 0 {
+					return uploads, nil, &FormError{fmt.Errorf("fields are larger than %d bytes", maxFieldsSize)}
+				}
+				part.Close()
+				values[part.FormName()] = append(values[part.FormName()], string(value))
+				continue
+			}
+			if part.FormName() != "file" {
+				part.Close()
+				continue
+			}
+
+			r, err := quota.add(part)
+			if err != nil {
+				return uploads, nil, err
+			}
+			file, err := config.stage(part.FileName(), r)
+			if err != nil {
+				if _, ok := err.(*PolicyError); !ok {
+					err = &FormError{err}
+				}
+				return uploads, nil, err
+			}
+			part.Close()
+			quota.staged(file)
+			uploads = append(uploads, file)
+		}
+
+		sums, err := ExpectedChecksums(header, values["sha256"], len(uploads))
+		if err != nil {
+			return uploads, nil, &FormError{err}
+		}
+		for i, sum := range sums {
+			uploads[i].Sha256 = sum
+		}
+
+		return uploads, values, nil
+	})
+}
+
+// store checks the files staged by read against their expected SHA-256, extracts archives when the form asks for it,
+// checks the files against the Policy, runs the Scanners, wraps the files into zips with a ZipPassword,
+// checks them against the Duplicates mode, moves them into UploadsDir and writes their sidecars
+// Staged files which are not accepted are removed
+// Each file is journaled before and after it is moved, so an interrupted upload is found by Recover,
+// and no push job runs while files are moved
+// It returns the duplicates to warn about and the quarantined files, and records the upload in the audit log
+func (config *Config) store(ctx context.Context, read func() ([]upload, map[string][]string, error)) (result UploadResult, err error) {
+	event := AuditEvent{Action: AuditUpload, Status: AuditOK}
+	defer func() {
+		if err != nil {
+			event.Status = AuditFailed
+			event.Error = err.Error()
+			switch err.(type) {
+			case *PolicyError, *DuplicateError, *ChecksumError, *FormError, *ScanError:
+				event.Status = AuditRefused
+			}
+		}
+		config.audit(ctx, event)
+	}()
+
+	uploads, values, err := read()
+	defer func() {
+		removeStaged(uploads)
+	}()
+	if err != nil {
+		return result, err
+	}
+
+	if err := checkChecksums(uploads); err != nil {
+		return result, err
+	}
+
+	if uploads, err = config.extractArchives(uploads, values); err != nil {
+		return result, err
+	}
+
+	if err := config.checkUpload(ctx, uploads); err != nil {
+		return result, err
+	}
+
+	if uploads, result.Quarantined, err = config.scanUploads(ctx, uploads); err != nil {
+		return result, err
+	}
+
+	if err := config.wrapUploads(uploads); err != nil {
+		return result, err
+	}
+
+	duplicates, err := config.findDuplicates(ctx, uploads)
+	if err != nil {
+		return result, err
+	}
+	if len(duplicates) > 0 && config.Duplicates == DuplicatesRefuse {
+		return result, &DuplicateError{duplicates}
+	}
+
+	config.pushMu.RLock()
+	defer config.pushMu.RUnlock()
+
+	uploaded := time.Now()
+	for i := range uploads {
+		file := &uploads[i]
+		path := filepath.ToSlash(file.Path)
+		event.Files = append(event.Files, path)
+
+		record := UploadRecord{Path: path, Oid: file.Oid, Size: file.Size, Time: uploaded.UTC()}
+		if err := config.journalUpload(ctx, record); err != nil {
+			return result, err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file.Path), os.ModePerm); err != nil {
+			return result, fmt.Errorf("Error when creating %v", filepath.Dir(file.Path))
+		}
+
+		if err := os.Rename(file.Temp, file.Path); err != nil {
+			return result, fmt.Errorf("Error when storing %v: %s", file.Filename, err.Error())
+		}
+		file.Temp = ""
+		syncDir(filepath.Dir(file.Path))
+		config.Metrics.upload(file.Size)
+
+		if err := config.writeMetadata(file.Path, config.uploadMetadata(*file, values, uploaded)); err != nil {
+			return result, fmt.Errorf("Error when writing metadata of %v", file.Filename)
+		}
+
+		record.Stored = true
+		if err := config.journalUpload(ctx, record); err != nil {
+			return result, err
+		}
+	}
+
+	result.Message = "Files are uploaded"
+	result.Duplicates = duplicates
+	return result, nil
+}
+
+// stage copies a file to a synced temporary file in UploadsDir, hashing, counting and sniffing it on the way,
+// and resolves where it is stored
+// A file with a refused extension is not read, a file larger than MaxFileSize is not read further
+// The temporary file is removed when the file cannot be staged
+func (config *Config) stage(filename string, r io.Reader) (file upload, err error) {
+	file = upload{Source: Source{Filename: filename}}
+
+	if err := config.checkExtension(filename); err != nil {
+		return file, err
+	}
+
+	if err := os.MkdirAll(config.UploadsDir, os.ModePerm); err != nil {
+		return file, err
+	}
+	tmp, err := ioutil.TempFile(config.UploadsDir, ".upload.*"+tempSuffix)
+	if err != nil {
+		return file, err
+	}
+	file.Temp = tmp.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(file.Temp)
+			file.Temp = ""
+		}
+	}()
+
+	if limit := config.Policy.MaxFileSize; limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+
+	hash := sha256.New()
+	head := &headWriter{limit: 512}
+	file.Size, err = io.Copy(io.MultiWriter(tmp, hash, head), r)
+	if err == nil {
+		// TempFile is only readable by its owner
+		err = tmp.Chmod(0644)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return file, err
+	}
+
+	if limit := config.Policy.MaxFileSize; limit > 0 && file.Size > limit {
+		return file, &PolicyError{http.StatusRequestEntityTooLarge, filename, fmt.Sprintf("file is larger than %d bytes", limit)}
+	}
+
+	file.Oid = hex.EncodeToString(hash.Sum(nil))
+	file.Type = mediaType(http.DetectContentType(head.data))
+	file.Path = config.UploadPath(filename, file.Oid)
+	return file, nil
+}
+
+// checkChecksums refuses the first file without the SHA-256 its client expects
+func checkChecksums(uploads []upload) error {
+	for _, file := range uploads {
+		if file.Sha256 != "" && file.Sha256 != file.Oid {
+			return &ChecksumError{file.Filename, file.Sha256, file.Oid}
+		}
+	}
+	return nil
+}
+
+// removeStaged removes the temporary files of uploads which were not stored
+func removeStaged(uploads []upload) {
+	for _, file := range uploads {
+		if file.Temp != "" {
+			os.Remove(file.Temp)
+		}
+	}
+}
+
+// headWriter keeps the beginning of what is written, up to limit bytes
+type headWriter struct {
+	data  []byte
+	limit int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if n := w.limit - len(w.data); n > 0 {
+		if n > len(p) {
+			n = len(p)
+		}
+		w.data = append(w.data, p[:n]...)
+	}
+	return len(p), nil
+}
+
+// writeAtomic writes path through a temporary file in the same directory, which is synced then renamed
+// The temporary file is removed when write fails
+func writeAtomic(path string, write func(io.Writer) error) error {
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".*"+tempSuffix)
+	if err != nil {
+		return err
+	}
+
+	// TempFile is only readable by its owner
+	err = tmp.Chmod(0644)
+	if err == nil {
+		err = write(tmp)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir syncs a directory so a rename in it survives a crash, where the system allows it
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// RemoveTemps removes the temporary files abandoned in UploadsDir by interrupted uploads and returns their paths
+// It must only run while no upload is running
+func (config *Config) RemoveTemps() ([]string, error) {
+	var removed []string
+
+	err := filepath.Walk(config.UploadsDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && strings.HasSuffix(path, tempSuffix) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed = append(removed, path)
+		}
+		return nil
+	})
+
+	return removed, err
+}
+
+// ExcludeTemps keeps the temporary files of uploads out of git status and git add, in .git/info/exclude
+func (config *Config) ExcludeTemps(ctx context.Context) error {
+	out, err := config.git(ctx, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return err
+	}
+	exclude := strings.TrimSpace(string(out))
+
+	content, err := ioutil.ReadFile(exclude)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == tempPattern {
+			return nil
+		}
+	}
+
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	content = append(content, tempPattern+"\n"...)
+
+	if err := os.MkdirAll(filepath.Dir(exclude), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(exclude, content, 0644)
+}
@@ -0,0 +1,341 @@
+package gitcommand
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func TestPathSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hello.txt")
+	if err := ioutil.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := PathSources([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sources[0].Filename != "hello.txt" || sources[0].Size != 5 {
+		t.Fatalf("got %s of %d bytes, want hello.txt of 5 bytes", sources[0].Filename, sources[0].Size)
+	}
+
+	src, err := sources[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(src)
+	src.Close()
+	if err != nil || string(content) != "hello" {
+		t.Fatalf("got %q, %v", content, err)
+	}
+
+	if _, err := PathSources([]string{dir}); err == nil {
+		t.Fatal("a directory should not be a source")
+	}
+	if _, err := PathSources([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Fatal("a missing file should not be a source")
+	}
+}
+
+func TestStage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		filename string
+		content  string
+		policy   Policy
+		oid      string
+		typ      string
+		status   int
+	}{
+		{"hello.txt", "hello", Policy{}, helloOid, "text/plain", 0},
+		{"hello.txt", "hello", Policy{MaxFileSize: 5}, helloOid, "text/plain", 0},
+		{"hello.txt", "hello", Policy{MaxFileSize: 4}, "", "", http.StatusRequestEntityTooLarge},
+		{"hello.exe", "hello", Policy{BlockedExtensions: []string{"exe"}}, "", "", http.StatusUnsupportedMediaType},
+		{"page.html", "<html><body>", Policy{}, "", "text/html", 0},
+	}
+
+	for _, c := range cases {
+		config := &Config{UploadsDir: filepath.Join(dir, "uploads"), Policy: c.policy}
+		file, err := config.stage(c.filename, strings.NewReader(c.content))
+		if c.status != 0 {
+			if policyErr, ok := err.(*PolicyError); !ok || policyErr.Status != c.status {
+				t.Fatalf("%s: got %v, want status %d", c.filename, err, c.status)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (c.oid != "" && file.Oid != c.oid) || file.Type != c.typ || file.Size != int64(len(c.content)) {
+			t.Fatalf("%s: got %s %s of %d bytes", c.filename, file.Oid, file.Type, file.Size)
+		}
+		if file.Path != filepath.Join(config.UploadsDir, c.filename) || filepath.Dir(file.Temp) != config.UploadsDir || !strings.HasSuffix(file.Temp, tempSuffix) {
+			t.Fatalf("%s: staged in %s for %s", c.filename, file.Temp, file.Path)
+		}
+		removeStaged([]upload{file})
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "uploads", tempPattern))
+	if len(matches) != 0 {
+		t.Fatal(matches)
+	}
+}
+
+func TestRemoveTemps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	temp := filepath.Join(dir, "ab", ".hello.txt.123"+tempSuffix)
+	os.MkdirAll(filepath.Dir(temp), os.ModePerm)
+	for _, path := range []string{temp, filepath.Join(dir, "hello.txt")} {
+		if err := ioutil.WriteFile(path, []byte("hello"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &Config{UploadsDir: dir}
+	removed, err := config.RemoveTemps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != temp {
+		t.Fatal(removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "hello.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpectedChecksums(t *testing.T) {
+	upper := "2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824"
+
+	cases := []struct {
+		header string
+		values []string
+		n      int
+		sums   []string
+		valid  bool
+	}{
+		{"", nil, 1, nil, true},
+		{helloOid, nil, 1, []string{helloOid}, true},
+		{helloOid + ", " + helloOid, nil, 2, []string{helloOid, helloOid}, true},
+		{"", []string{upper}, 1, []string{helloOid}, true},
+		{"ignored", []string{helloOid}, 1, []string{helloOid}, true},
+		{helloOid, nil, 2, nil, false},
+		{"hello", nil, 1, nil, false},
+	}
+
+	for _, c := range cases {
+		sums, err := ExpectedChecksums(c.header, c.values, c.n)
+		if (err == nil) != c.valid || !reflect.DeepEqual(sums, c.sums) {
+			t.Fatalf("%q %v: got %v, %v", c.header, c.values, sums, err)
+		}
+	}
+}
+
+func TestCheckChecksums(t *testing.T) {
+	file := upload{Source: Source{Filename: "hello.txt"}, Oid: helloOid}
+
+	cases := []struct {
+		sha256 string
+		valid  bool
+	}{
+		{"", true},
+		{helloOid, true},
+		{strings.Repeat("0", 64), false},
+	}
+
+	for _, c := range cases {
+		file.Sha256 = c.sha256
+		err := checkChecksums([]upload{file})
+		if _, ok := err.(*ChecksumError); ok == c.valid || (err == nil) != c.valid {
+			t.Fatalf("%q: got %v", c.sha256, err)
+		}
+	}
+}
+
+// countReader counts the bytes read from it
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// postUpload streams an upload form of the given files to HandleUpload and returns the status and how much of
+// the files was sent
+func postUpload(t *testing.T, config *Config, files map[string]io.Reader) (int, int64) {
+	body, pipe := io.Pipe()
+	form := multipart.NewWriter(pipe)
+	sent := &countReader{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for name, r := range files {
+			part, err := form.CreateFormFile("file", name)
+			if err != nil {
+				pipe.CloseWithError(err)
+				return
+			}
+			sent.r = r
+			if _, err := io.Copy(part, sent); err != nil {
+				pipe.CloseWithError(err)
+				return
+			}
+		}
+		pipe.CloseWithError(form.Close())
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	if err := config.HandleUpload(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	<-done
+	return rec.Code, sent.n
+}
+
+func TestHandleUpload(t *testing.T) {
+	defer testRepo(t)()
+
+	config := &Config{Branch: "master", Remote: "origin", UploadsDir: "sample-files", Metadata: MetadataJSON}
+	status, _ := postUpload(t, config, map[string]io.Reader{"hello.txt": strings.NewReader("hello")})
+	if status != http.StatusOK {
+		t.Fatalf("got %d", status)
+	}
+	for _, path := range []string{"sample-files/hello.txt", "sample-files/hello.txt.meta.json"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := config.readUploads(context.Background())
+	if err != nil || len(records) != 1 || !records[0].Stored {
+		t.Fatalf("got %v, %v", records, err)
+	}
+
+	// hello.txt waits for the next push, so a single file is left
+	config.Policy.MaxFiles = 2
+	status, _ = postUpload(t, config, map[string]io.Reader{"a.txt": strings.NewReader("a"), "b.txt": strings.NewReader("b")})
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got %d", status)
+	}
+
+	// An upload over MaxPushSize is not read to the end
+	config.Policy = Policy{MaxPushSize: 1 << 20}
+	status, sent := postUpload(t, config, map[string]io.Reader{"large.bin": io.LimitReader(zeroReader{}, 64<<20)})
+	if status != http.StatusRequestEntityTooLarge || sent > 4<<20 {
+		t.Fatalf("got %d after %d bytes", status, sent)
+	}
+
+	for _, path := range []string{"sample-files/a.txt", "sample-files/b.txt", "sample-files/large.bin"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s should not be stored", path)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join("sample-files", tempPattern))
+	if len(matches) != 0 {
+		t.Fatal(matches)
+	}
+}
+
+// zeroReader reads zeros forever
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestStoreDuringPush(t *testing.T) {
+	defer testRepo(t)()
+	testCommit(t, attributesFile, "")
+	testGit(t, "push", "-q", "origin", "master")
+
+	ctx := context.Background()
+	config := &Config{Branch: "master", Remote: "origin", UploadsDir: "sample-files", Metadata: MetadataJSON}
+	if err := config.ExcludeTemps(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Uploads and pushes run side by side, no commit may take a file without its sidecar
+	errs := make(chan error, 10)
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		go func() {
+			_, err := config.StoreFiles(ctx, []Source{{Filename: name, Open: func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader(name)), nil
+			}}}, nil)
+			errs <- err
+		}()
+		go func() {
+			_, err := config.RunPush(ctx)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err != nil && !strings.Contains(err.Error(), "nothing to commit") {
+			t.Fatal(err)
+		}
+	}
+	if _, err := config.RunPush(ctx); err != nil && !strings.Contains(err.Error(), "nothing to commit") {
+		t.Fatal(err)
+	}
+
+	for _, commit := range strings.Fields(testGit(t, "rev-list", "origin/master")) {
+		files := strings.Fields(testGit(t, "diff-tree", "--no-commit-id", "--name-only", "-r", "--root", commit))
+		for _, file := range files {
+			if isMetadata(file) || !strings.HasPrefix(file, "sample-files/") {
+				continue
+			}
+			if !containsString(files, file+".meta.json") {
+				t.Fatalf("commit %s has %s without its sidecar: %v", commit, file, files)
+			}
+		}
+	}
+	if status := testGit(t, "status", "--porcelain"); status != "" {
+		t.Fatalf("got\n%s", status)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
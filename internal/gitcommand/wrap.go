@@ -0,0 +1,205 @@
+package gitcommand
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// DefaultZipPassword is the password malware samples are usually shared with
+const DefaultZipPassword = "infected"
+
+// zipSuffix is appended to the name of a file wrapped into a zip
+const zipSuffix = ".zip"
+
+// zipEncrypted is the general purpose flag of an encrypted zip entry
+const zipEncrypted = 0x1
+
+// zipModifiedDate is the MS-DOS date of wrapped entries, 1980-01-01, a fixed one keeps the zip of a file the same
+// between uploads, CreateRaw does not convert Modified
+const zipModifiedDate = 1<<5 | 1
+
+// wrapped is a file as it was uploaded, before it was wrapped into a zip
+type wrapped struct {
+	Oid  string
+	Size int64
+}
+
+// wrapUploads wraps each staged file into a zip encrypted with ZipPassword, when it is set
+// The zips replace the staged files, named after them with a .zip suffix
+func (config *Config) wrapUploads(uploads []upload) error {
+	if config.ZipPassword == "" {
+		return nil
+	}
+
+	for i := range uploads {
+		if err := config.wrapUpload(&uploads[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wrapUpload writes the zip of a staged file to a new temporary file and stages it instead
+func (config *Config) wrapUpload(file *upload) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file.Temp), ".upload.*"+tempSuffix)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	counter := &countWriter{}
+	err = config.writeZip(io.MultiWriter(tmp, hash, counter), *file)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	os.Remove(file.Temp)
+	file.Temp = tmp.Name()
+	file.Wrapped = &wrapped{file.Oid, file.Size}
+	file.Oid = hex.EncodeToString(hash.Sum(nil))
+	file.Size = counter.n
+	if config.contentAddressed() {
+		file.Path = config.UploadPath(file.Filename+zipSuffix, file.Oid)
+	} else {
+		file.Path += zipSuffix
+	}
+	return nil
+}
+
+// writeZip writes a zip holding the staged file, deflated and encrypted with the traditional PKWARE encryption
+// that zip -P and 7-Zip use by default for zips, so any tool can open it
+// The file is read twice, once for the CRC-32 and the compressed size the entry starts with
+func (config *Config) writeZip(w io.Writer, file upload) error {
+	crc := crc32.NewIEEE()
+	compressed := &countWriter{}
+	if err := deflateFile(file.Temp, compressed, crc); err != nil {
+		return err
+	}
+
+	header := &zip.FileHeader{
+		Name:               path.Base(filepath.ToSlash(file.Filename)),
+		Method:             zip.Deflate,
+		Flags:              zipEncrypted,
+		ModifiedDate:       zipModifiedDate,
+		CRC32:              crc.Sum32(),
+		CompressedSize64:   uint64(compressed.n) + zipCryptoHeaderSize,
+		UncompressedSize64: uint64(file.Size),
+	}
+	header.SetMode(0644)
+
+	archive := zip.NewWriter(w)
+	raw, err := archive.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+
+	// The random bytes of the encryption header come from the file and the password,
+	// so the same file gets the same zip, and the same LFS object, on each upload
+	seed := sha256.Sum256([]byte(config.ZipPassword + "\x00" + file.Oid))
+	encrypter := newZipCrypto(raw, config.ZipPassword)
+	encryptionHeader := append(seed[:zipCryptoHeaderSize-1], byte(header.CRC32>>24))
+	if _, err := encrypter.Write(encryptionHeader); err != nil {
+		return err
+	}
+	if err := deflateFile(file.Temp, encrypter, nil); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// deflateFile compresses the file at path into w, also writing its content to plain when it is not nil
+func deflateFile(path string, w io.Writer, plain io.Writer) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var r io.Reader = src
+	if plain != nil {
+		r = io.TeeReader(src, plain)
+	}
+
+	deflater, err := flate.NewWriter(w, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(deflater, r); err != nil {
+		return err
+	}
+	return deflater.Close()
+}
+
+// countWriter counts the bytes written to it
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// zipCryptoHeaderSize is the size of the encryption header starting an encrypted entry
+const zipCryptoHeaderSize = 12
+
+// zipCrypto encrypts what is written to it with the traditional PKWARE encryption
+type zipCrypto struct {
+	w    io.Writer
+	keys [3]uint32
+	buf  []byte
+}
+
+func newZipCrypto(w io.Writer, password string) *zipCrypto {
+	z := &zipCrypto{w: w, keys: [3]uint32{0x12345678, 0x23456789, 0x34567890}}
+	for i := 0; i < len(password); i++ {
+		z.update(password[i])
+	}
+	return z
+}
+
+// update mixes a plain byte into the keys
+func (z *zipCrypto) update(b byte) {
+	z.keys[0] = crc32Update(z.keys[0], b)
+	z.keys[1] = (z.keys[1]+z.keys[0]&0xff)*134775813 + 1
+	z.keys[2] = crc32Update(z.keys[2], byte(z.keys[1]>>24))
+}
+
+// stream returns the next byte of the key stream
+func (z *zipCrypto) stream() byte {
+	temp := z.keys[2] | 2
+	return byte((temp * (temp ^ 1)) >> 8)
+}
+
+func (z *zipCrypto) Write(p []byte) (int, error) {
+	z.buf = append(z.buf[:0], p...)
+	for i, b := range z.buf {
+		z.buf[i] = b ^ z.stream()
+		z.update(b)
+	}
+	return z.w.Write(z.buf)
+}
+
+// crc32Update adds a byte to a CRC-32 the way the PKWARE encryption does, without the usual inversions
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
@@ -0,0 +1,168 @@
+package gitcommand
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unzipEncrypted decrypts and inflates the only entry of an encrypted zip
+func unzipEncrypted(t *testing.T, content []byte, password string) (string, []byte) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Flags&zipEncrypted == 0 {
+		t.Fatalf("got %d entries, want 1 encrypted", len(reader.File))
+	}
+	entry := reader.File[0]
+
+	raw, err := entry.OpenRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := ioutil.ReadAll(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z := newZipCrypto(nil, password)
+	plain := make([]byte, len(encrypted))
+	for i, c := range encrypted {
+		plain[i] = c ^ z.stream()
+		z.update(plain[i])
+	}
+	// The last byte of the header checks the password, the high byte of the time when a data descriptor follows
+	check := byte(entry.CRC32 >> 24)
+	if entry.Flags&0x8 != 0 {
+		check = byte(entry.ModifiedTime >> 8)
+	}
+	if plain[zipCryptoHeaderSize-1] != check {
+		t.Fatal("wrong password")
+	}
+
+	data, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(plain[zipCryptoHeaderSize:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry.Name, data
+}
+
+func TestUnzipInfoZip(t *testing.T) {
+	// infozip.zip was made by Info-ZIP 3.0 with zip -X -P infected, from 100 times "MZ sample "
+	zipped, err := ioutil.ReadFile(filepath.Join("testdata", "infozip.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, data := unzipEncrypted(t, zipped, DefaultZipPassword)
+	if name != "dropper.exe" || string(data) != strings.Repeat("MZ sample ", 100) {
+		t.Fatalf("got %s of %d bytes", name, len(data))
+	}
+}
+
+func TestWrapUploadUnzip(t *testing.T) {
+	if _, err := exec.LookPath("unzip"); err != nil {
+		t.Skip("unzip is not installed")
+	}
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Zips must open with the usual tools, not only with this package
+	content := strings.Repeat("MZ sample ", 1000)
+	config := &Config{UploadsDir: dir, ZipPassword: DefaultZipPassword}
+	file, err := config.stage("dropper.exe", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads := []upload{file}
+	defer removeStaged(uploads)
+	if err := config.wrapUploads(uploads); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command("unzip", "-p", "-P", DefaultZipPassword, uploads[0].Temp, "dropper.exe").Output()
+	if err != nil || string(out) != content {
+		t.Fatalf("got %d bytes, %v", len(out), err)
+	}
+}
+
+func TestWrapUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "add2git-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := strings.Repeat("MZ sample ", 1000)
+
+	cases := []struct {
+		naming   string
+		filename string
+		password string
+		path     string
+	}{
+		{NamingOriginal, "dropper.exe", DefaultZipPassword, "dropper.exe.zip"},
+		{NamingOriginal, "dir/dropper.exe", "secret", "dropper.exe.zip"},
+		{NamingSha256, "dropper.exe", DefaultZipPassword, ""},
+	}
+
+	for _, c := range cases {
+		config := &Config{UploadsDir: dir, Naming: c.naming, ZipPassword: c.password}
+
+		var oids []string
+		for i := 0; i < 2; i++ {
+			file, err := config.stage(c.filename, strings.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sample := file.Oid
+			uploads := []upload{file}
+			if err := config.wrapUploads(uploads); err != nil {
+				t.Fatal(err)
+			}
+			file = uploads[0]
+
+			if file.Wrapped == nil || file.Wrapped.Oid != sample || file.Wrapped.Size != int64(len(content)) {
+				t.Fatalf("%s: got %+v", c.filename, file.Wrapped)
+			}
+			path := c.path
+			if path == "" {
+				path = file.Oid
+			}
+			if file.Path != filepath.Join(dir, path) {
+				t.Fatalf("%s: got %s, want %s", c.filename, file.Path, path)
+			}
+
+			zipped, err := ioutil.ReadFile(file.Temp)
+			if err != nil || int64(len(zipped)) != file.Size {
+				t.Fatalf("%s: got %d bytes, %v", c.filename, len(zipped), err)
+			}
+			name, data := unzipEncrypted(t, zipped, c.password)
+			if name != "dropper.exe" || string(data) != content {
+				t.Fatalf("%s: got %s of %d bytes", c.filename, name, len(data))
+			}
+
+			oids = append(oids, file.Oid)
+			removeStaged(uploads)
+		}
+
+		if oids[0] != oids[1] {
+			t.Fatalf("%s: the same file got two zips", c.filename)
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, tempPattern))
+	if len(matches) != 0 {
+		t.Fatal(matches)
+	}
+}
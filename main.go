@@ -1,63 +1,279 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	rice "github.com/GeertJohan/go.rice"
 	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/saguywalker/add2git-lfs/internal/gitcommand"
 )
 
+// version is set when building a release with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// command is a subcommand of add2git-lfs, run adds its flags to the flag set and parses args
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(flags *flag.FlagSet, args []string) int
+}
+
+// commands are the subcommands in the order of the help, serve runs when none is given
+var commands = []command{
+	{"serve", "", "Serve the web application to upload, browse and push files", runServe},
+	{"push", "<files...>", "Copy files into the folder, commit and push them", runPush},
+	{"status", "", "Show the pending files, the unpushed commits and the last push jobs", runStatus},
+	{"doctor", "", "Check git, git-lfs, the remote, credentials and .gitattributes", runDoctor},
+	{"config", "", "Print the configuration resolved from the flags and the repository", runConfig},
+	{"version", "", "Print the version", runVersion},
+}
+
 func main() {
-	branch := flag.String("branch", "master", "branch")
-	email := flag.String("email", "", "user.email for commit")
-	port := flag.Int("port", 12358, "port for webapp")
-	remote := flag.String("remote", "origin", "remote")
-	token := flag.String("token", "", "personal access token")
-	uploadsDir := flag.String("folder", "sample-files", "folder to upload files")
-	user := flag.String("user", "", "user.name for commit")
+	args := os.Args[1:]
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	flag.Parse()
+	if name == "help" {
+		os.Exit(runHelp(args))
+	}
 
-	config := gitcommand.NewConfig(*branch, *email, runtime.GOOS, *remote, *token, *uploadsDir, *user)
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(cmd.run(newFlagSet(cmd), args))
+}
 
-	if config.User != "" {
-		if err := config.ConfigUser("Name"); err != nil {
-			panic(err)
+// findCommand returns the subcommand called name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
 	}
+	return command{}, false
+}
 
-	if *email != "" {
-		if err := config.ConfigUser("Email"); err != nil {
-			panic(err)
-		}
+// newFlagSet returns the flag set of a subcommand with its help text
+func newFlagSet(cmd command) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() {
+		w := flags.Output()
+		synopsis := strings.TrimSpace(fmt.Sprintf("add2git-lfs %s [flags] %s", cmd.name, cmd.args))
+		fmt.Fprintf(w, "usage: %s\n\n%s\n\nflags:\n", synopsis, cmd.summary)
+		flags.PrintDefaults()
 	}
+	return flags
+}
 
-	os.MkdirAll(filepath.Join(".", config.UploadsDir), os.ModePerm)
-	err := config.InitLfs()
+// usage prints the subcommands
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: add2git-lfs [command] [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nserve runs when no command is given, see add2git-lfs help <command> for its flags\n")
+}
+
+// runHelp prints the help of a subcommand, or the subcommands
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return 0
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		return 2
+	}
+	cmd.run(newFlagSet(cmd), []string{"-h"})
+	return 0
+}
+
+// runServe serves the web application until it fails
+func runServe(flags *flag.FlagSet, args []string) int {
+	opts := defaultOptions()
+	opts.repositoryFlags(flags)
+	opts.authorFlags(flags)
+	opts.storageFlags(flags)
+	opts.auditFlags(flags)
+	opts.freeSpaceFlag(flags)
+	opts.recoverFlag(flags)
+	allowedHosts := flags.String("allowed-hosts", "", "comma-separated extra host names of the server, e.g. behind a proxy")
+	bind := flags.String("bind", "127.0.0.1", "address to listen on (0.0.0.0: every interface)")
+	corsOrigins := flags.String("cors-origins", "", "comma-separated origins of other sites allowed to call the API")
+	noBrowser := flags.Bool("no-browser", false, "do not open a browser on start")
+	port := flags.Int("port", 12358, "port for webapp")
+	redirectPort := flags.Int("redirect-port", 0, "port redirecting HTTP to HTTPS with TLS (0: disabled)")
+	shutdownTimeout := flags.Duration("shutdown-timeout", time.Minute, "time given to running uploads and pushes on SIGINT or SIGTERM")
+	tlsCert := flags.String("tls-cert", "", "TLS certificate file, with -tls-key")
+	tlsKey := flags.String("tls-key", "", "TLS key file, with -tls-cert")
+	tlsSelfSigned := flags.Bool("tls-self-signed", false, "serve HTTPS with a self-signed certificate generated on first run")
+	trustedProxy := flags.String("trusted-proxy", "", "comma-separated addresses or CIDR ranges of authenticating proxies whose X-Forwarded-User is recorded")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	logger := opts.logger()
+	if (*tlsCert == "") != (*tlsKey == "") {
+		fatal(logger, "invalid flag", errors.New("-tls-cert and -tls-key go together"))
+	}
+	config := opts.config(logger)
+	config.AllowedHosts = append(gitcommand.LocalHosts(*bind), gitcommand.SplitList(*allowedHosts)...)
+	config.AllowedOrigins = gitcommand.SplitList(*corsOrigins)
+	proxies, err := gitcommand.ParseTrustedProxies(gitcommand.SplitList(*trustedProxy))
 	if err != nil {
-		panic(err)
+		fatal(logger, "invalid flag", err)
+	}
+	config.TrustedProxies = proxies
+	opts.setup(ctx, config, logger, true)
+	if config.Audit != nil {
+		defer config.Audit.Close()
+	}
+
+	if *tlsCert == "" && *tlsSelfSigned {
+		if *tlsCert, *tlsKey, err = config.SelfSignedCertificate(ctx, gitcommand.LocalHosts(*bind)); err != nil {
+			fatal(logger, "cannot generate a self-signed certificate", err)
+		}
 	}
 
 	e := echo.New()
+	e.HideBanner = true
+	e.Use(gitcommand.RequestID, config.Identify, config.LogRequests, config.CheckOrigin, config.CORS(), config.CSRF, config.RefuseWhenStopping)
 
 	assetHandler := http.FileServer(rice.MustFindBox("public").HTTPBox())
 	e.GET("/", echo.WrapHandler(assetHandler))
 	e.GET("/static/*", echo.WrapHandler(http.StripPrefix("/static/", assetHandler)))
+	e.GET("/audit", config.HandleAudit)
+	e.GET("/files", config.HandleListFiles)
+	e.GET("/files/*", config.HandleDownload)
+	e.GET("/healthz", config.HandleHealth)
+	e.GET("/history", config.HandleHistory)
+	e.GET("/jobs", config.HandleJobs)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	e.GET("/readyz", config.HandleReady)
 	e.POST("/upload", config.HandleUpload)
 	e.POST("/pushfiles", config.HandlePushFiles)
 
-	go Open(fmt.Sprintf("http://127.0.0.1:%d", *port))
-	e.Logger.Fatal(e.Start(fmt.Sprintf(":%d", *port)))
+	address := net.JoinHostPort(*bind, strconv.Itoa(*port))
+	url := "http://" + net.JoinHostPort(browserHost(*bind), strconv.Itoa(*port))
+	if *tlsCert != "" {
+		url = "https://" + strings.TrimPrefix(url, "http://")
+
+		if *redirectPort != 0 {
+			redirect := net.JoinHostPort(*bind, strconv.Itoa(*redirectPort))
+			go func() {
+				err := http.ListenAndServe(redirect, gitcommand.RedirectHTTPS(*port))
+				logger.Warn("HTTP to HTTPS redirect stopped", "address", redirect, "error", err)
+			}()
+		}
+	}
+
+	if !*noBrowser {
+		go func() {
+			if err := Open(url); err != nil {
+				logger.Warn("cannot open a browser", "url", url, "error", err)
+			}
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	stopped := make(chan error, 1)
+	go func() {
+		if *tlsCert != "" {
+			stopped <- e.StartTLS(address, *tlsCert, *tlsKey)
+		} else {
+			stopped <- e.Start(address)
+		}
+	}()
+
+	logger.Info("serving", "url", url, "address", address, "branch", config.Branch, "remote", config.Remote, "folder", config.UploadsDir)
+	select {
+	case err := <-stopped:
+		logger.Error("server stopped", "error", err)
+		return 1
+	case sig := <-signals:
+		// A second signal stops the process right away
+		signal.Stop(signals)
+		logger.Info("shutting down", "signal", sig.String(), "timeout", *shutdownTimeout)
+	}
+
+	return shutdown(e, config, logger, *shutdownTimeout)
+}
+
+// shutdown refuses new uploads and pushes, waits for the running requests and git commands,
+// then removes the temporary files of uploads and returns the exit code
+// Locks left in the repository are not removed, they may belong to another git process and are left to the recovery
+func shutdown(e *echo.Echo, config *gitcommand.Config, logger *slog.Logger, timeout time.Duration) int {
+	config.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The handlers of requests still running go on after their connections are closed, and may start
+	// a git command at any time, their locks and files are left to the recovery on the next start
+	if err := e.Shutdown(ctx); err != nil {
+		logger.Error("requests still running, closing their connections and keeping temporary files", "error", err)
+		e.Close()
+		return 1
+	}
+
+	if err := config.WaitCommands(ctx); err != nil {
+		logger.Error("git commands still running, their locks are kept", "error", err)
+		return 1
+	}
+
+	removeTemps(config, logger)
+
+	logger.Info("stopped")
+	return 0
+}
+
+// browserHost returns the host to open in a browser for a server listening on bind
+func browserHost(bind string) string {
+	if ip := net.ParseIP(bind); bind == "" || (ip != nil && ip.IsUnspecified()) {
+		return "127.0.0.1"
+	}
+	return bind
+}
+
+// runVersion prints the version of add2git-lfs and of Go
+func runVersion(flags *flag.FlagSet, args []string) int {
+	flags.Parse(args)
+	fmt.Printf("add2git-lfs %s %s %s/%s\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return 0
+}
+
+// fatal logs an error and exits
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
 
-//Open a browser according to URL
+// Open a browser according to URL
 func Open(url string) error {
 	var cmd string
 	var args []string
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/saguywalker/add2git-lfs/internal/gitcommand"
+)
+
+// options are the flags shared by the subcommands, each one adds the groups it uses
+type options struct {
+	allowExt     string
+	allowType    string
+	auditKeep    int
+	auditLog     string
+	auditMaxSize int64
+	blockExt     string
+	blockType    string
+	branch       string
+	duplicates   string
+	email        string
+	lfsThreshold int64
+	logFormat    string
+	logLevel     string
+	maxEntries   int
+	maxExtracted int64
+	maxFiles     int
+	maxFileSize  int64
+	maxPushSize  int64
+	metadata     string
+	minFreeSpace int64
+	naming       string
+	recoverMode  string
+	remote       string
+	scan         listFlag
+	scanAction   string
+	scanTimeout  time.Duration
+	token        string
+	uploadsDir   string
+	user         string
+	zip          bool
+	zipPassword  string
+}
+
+// defaultOptions returns the options of a subcommand before parsing its flags
+func defaultOptions() *options {
+	return &options{
+		auditKeep:    5,
+		auditMaxSize: 10 << 20,
+		branch:       "master",
+		duplicates:   gitcommand.DuplicatesWarn,
+		logFormat:    "text",
+		logLevel:     "info",
+		maxEntries:   10000,
+		maxExtracted: 10 << 30,
+		metadata:     gitcommand.MetadataNone,
+		minFreeSpace: 1 << 30,
+		naming:       gitcommand.NamingOriginal,
+		recoverMode:  gitcommand.RecoverAsk,
+		remote:       "origin",
+		scanAction:   gitcommand.ScanBlock,
+		scanTimeout:  5 * time.Minute,
+		uploadsDir:   "sample-files",
+		zipPassword:  gitcommand.DefaultZipPassword,
+	}
+}
+
+// repositoryFlags adds the flags of the branch, the remote, the folder and the logs
+func (o *options) repositoryFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.branch, "branch", o.branch, "branch")
+	flags.StringVar(&o.logFormat, "log-format", o.logFormat, "log format: text or json")
+	flags.StringVar(&o.logLevel, "log-level", o.logLevel, "log level: debug, info, warn or error")
+	flags.StringVar(&o.remote, "remote", o.remote, "remote")
+	flags.StringVar(&o.uploadsDir, "folder", o.uploadsDir, "folder to upload files")
+}
+
+// authorFlags adds the flags of the commit author and the push credentials
+func (o *options) authorFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.email, "email", o.email, "user.email for commit")
+	flags.StringVar(&o.token, "token", o.token, "personal access token")
+	flags.StringVar(&o.user, "user", o.user, "user.name for commit")
+}
+
+// storageFlags adds the flags of the checks and the layout of uploaded files
+func (o *options) storageFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.allowExt, "allow-ext", o.allowExt, "comma-separated file extensions allowed for upload")
+	flags.StringVar(&o.allowType, "allow-type", o.allowType, "comma-separated MIME types allowed for upload, e.g. image/*")
+	flags.StringVar(&o.blockExt, "block-ext", o.blockExt, "comma-separated file extensions refused for upload")
+	flags.StringVar(&o.blockType, "block-type", o.blockType, "comma-separated MIME types refused for upload")
+	flags.StringVar(&o.duplicates, "duplicates", o.duplicates, "files already tracked by LFS: allow, warn or refuse")
+	flags.Int64Var(&o.lfsThreshold, "lfs-threshold", o.lfsThreshold, "size in bytes from which files go through LFS (0: all files)")
+	flags.IntVar(&o.maxEntries, "max-archive-entries", o.maxEntries, "maximum number of files extracted from an archive (0: no limit)")
+	flags.Int64Var(&o.maxExtracted, "max-archive-size", o.maxExtracted, "maximum size in bytes of the files extracted from an archive (0: no limit)")
+	flags.IntVar(&o.maxFiles, "max-files", o.maxFiles, "maximum number of files per commit (0: no limit)")
+	flags.Int64Var(&o.maxFileSize, "max-file-size", o.maxFileSize, "maximum size in bytes of an uploaded file (0: no limit)")
+	flags.Int64Var(&o.maxPushSize, "max-push-size", o.maxPushSize, "maximum size in bytes of the files in a push (0: no limit)")
+	flags.StringVar(&o.metadata, "metadata", o.metadata, "sidecar written next to each file: none, json or yaml")
+	flags.StringVar(&o.naming, "naming", o.naming, "name of stored files: original, sha256 or sharded (ab/cd/<sha256>)")
+	flags.Var(&o.scan, "scan", "scanner run on each file before it is committed, repeatable: a command where {} is the file, prefixed with output: when a match is printed rather than exited with 1, or an http(s) URL")
+	flags.StringVar(&o.scanAction, "scan-action", o.scanAction, "files a scanner detects: block, tag or quarantine")
+	flags.DurationVar(&o.scanTimeout, "scan-timeout", o.scanTimeout, "time a scanner has for a file")
+	flags.BoolVar(&o.zip, "zip", o.zip, "wrap each file into a password-protected zip before committing it")
+	flags.StringVar(&o.zipPassword, "zip-password", o.zipPassword, "password of the zips made by -zip")
+}
+
+// auditFlags adds the flags of the audit log
+func (o *options) auditFlags(flags *flag.FlagSet) {
+	flags.IntVar(&o.auditKeep, "audit-keep", o.auditKeep, "number of rotated audit logs to keep")
+	flags.StringVar(&o.auditLog, "audit-log", o.auditLog, "audit log file (default: in the git directory, none: disabled)")
+	flags.Int64Var(&o.auditMaxSize, "audit-max-size", o.auditMaxSize, "size in bytes from which the audit log is rotated")
+}
+
+// recoverFlag adds the flag of what to do with the work an interrupted server left
+func (o *options) recoverFlag(flags *flag.FlagSet) {
+	flags.StringVar(&o.recoverMode, "recover", o.recoverMode, "work left by an interrupted server: ask, resume, rollback or ignore")
+}
+
+// freeSpaceFlag adds the flag of the free disk space required to accept uploads
+func (o *options) freeSpaceFlag(flags *flag.FlagSet) {
+	flags.Int64Var(&o.minFreeSpace, "min-free-space", o.minFreeSpace, "free disk space in bytes under which /readyz fails")
+}
+
+// logger returns the logger of the log flags, which also becomes the default one
+func (o *options) logger() *slog.Logger {
+	logger, err := gitcommand.NewLogger(os.Stderr, o.logFormat, o.logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+	return logger
+}
+
+// config returns the Config of the options and exits on an invalid mode
+func (o *options) config(logger *slog.Logger) *gitcommand.Config {
+	if !gitcommand.ValidDuplicates(o.duplicates) {
+		fatal(logger, "invalid flag", fmt.Errorf("unknown duplicates mode %q", o.duplicates))
+	}
+
+	if !gitcommand.ValidMetadata(o.metadata) {
+		fatal(logger, "invalid flag", fmt.Errorf("unknown metadata format %q", o.metadata))
+	}
+
+	if !gitcommand.ValidNaming(o.naming) {
+		fatal(logger, "invalid flag", fmt.Errorf("unknown naming mode %q", o.naming))
+	}
+
+	if !gitcommand.ValidScanAction(o.scanAction) {
+		fatal(logger, "invalid flag", fmt.Errorf("unknown scan action %q", o.scanAction))
+	}
+
+	if !gitcommand.ValidRecover(o.recoverMode) {
+		fatal(logger, "invalid flag", fmt.Errorf("unknown recover mode %q", o.recoverMode))
+	}
+
+	config := gitcommand.NewConfig(o.branch, o.email, runtime.GOOS, o.remote, o.token, o.uploadsDir, o.user)
+	config.Duplicates = o.duplicates
+	config.LfsThreshold = o.lfsThreshold
+	config.Logger = logger
+	config.Metadata = o.metadata
+	config.MinFreeSpace = o.minFreeSpace
+	config.Naming = o.naming
+	for _, spec := range o.scan {
+		scanner, err := config.ParseScanner(spec)
+		if err != nil {
+			fatal(logger, "invalid flag", fmt.Errorf("scanner %q: %s", spec, err.Error()))
+		}
+		config.Scanners = append(config.Scanners, scanner)
+	}
+	config.ScanAction = o.scanAction
+	config.ScanTimeout = o.scanTimeout
+	if o.zip {
+		if o.zipPassword == "" {
+			fatal(logger, "invalid flag", fmt.Errorf("-zip needs a -zip-password"))
+		}
+		config.ZipPassword = o.zipPassword
+	}
+	config.Policy = gitcommand.Policy{
+		MaxFileSize:       o.maxFileSize,
+		MaxPushSize:       o.maxPushSize,
+		MaxFiles:          o.maxFiles,
+		AllowedExtensions: gitcommand.SplitList(o.allowExt),
+		BlockedExtensions: gitcommand.SplitList(o.blockExt),
+		AllowedTypes:      gitcommand.SplitList(o.allowType),
+		BlockedTypes:      gitcommand.SplitList(o.blockType),
+		MaxArchiveEntries: o.maxEntries,
+		MaxArchiveSize:    o.maxExtracted,
+	}
+
+	return config
+}
+
+// setup configures the commit author, opens the audit log, creates the folder and initializes git lfs
+// A server, which owns the repository, first recovers the work an interrupted one left and removes abandoned
+// temporary files, another command would take the locks and the files of a running server for abandoned ones
+func (o *options) setup(ctx context.Context, config *gitcommand.Config, logger *slog.Logger, server bool) {
+	config.Metrics = gitcommand.NewMetrics(prometheus.DefaultRegisterer)
+
+	if config.User != "" {
+		if err := config.ConfigUser(ctx, "Name"); err != nil {
+			fatal(logger, "cannot configure user.name", err)
+		}
+	}
+
+	if config.Email != "" {
+		if err := config.ConfigUser(ctx, "Email"); err != nil {
+			fatal(logger, "cannot configure user.email", err)
+		}
+	}
+
+	if o.auditLog != "none" {
+		path := o.auditLog
+		if path == "" {
+			var err error
+			if path, err = config.DefaultAuditLog(ctx); err != nil {
+				fatal(logger, "cannot locate the audit log", err)
+			}
+		}
+
+		audit, err := gitcommand.OpenAuditLog(path, o.auditMaxSize, o.auditKeep)
+		if err != nil {
+			fatal(logger, "cannot open the audit log", err)
+		}
+		config.Audit = audit
+	}
+
+	// Stale locks would make git lfs fail, recovery comes first
+	if server {
+		o.recover(ctx, config, logger)
+	}
+
+	if err := config.ExcludeTemps(ctx); err != nil {
+		fatal(logger, "cannot exclude temporary files from git", err)
+	}
+	if server {
+		removeTemps(config, logger)
+	}
+
+	os.MkdirAll(filepath.Join(".", config.UploadsDir), os.ModePerm)
+	if err := config.InitLfs(ctx); err != nil {
+		fatal(logger, "cannot initialize git lfs", err)
+	}
+}
+
+// removeTemps removes the temporary files of uploads, once none is running
+func removeTemps(config *gitcommand.Config, logger *slog.Logger) {
+	removed, err := config.RemoveTemps()
+	for _, path := range removed {
+		logger.Warn("removed an abandoned temporary file", "path", path)
+	}
+	if err != nil {
+		logger.Error("cannot remove abandoned temporary files", "error", err)
+	}
+}
+
+// recover resumes or rolls back the work an interrupted server left, asking on a terminal in the ask mode
+func (o *options) recover(ctx context.Context, config *gitcommand.Config, logger *slog.Logger) {
+	recovery, err := config.Recover(ctx)
+	if err != nil {
+		fatal(logger, "cannot look for interrupted work", err)
+	}
+	if recovery.Empty() {
+		return
+	}
+
+	mode := o.recoverMode
+	if mode == gitcommand.RecoverAsk {
+		mode = askRecover(recovery)
+	}
+
+	switch mode {
+	case gitcommand.RecoverResume:
+		job, err := config.Resume(ctx, recovery)
+		if err != nil {
+			fatal(logger, "cannot resume the interrupted work", err)
+		}
+		if job != nil {
+			logger.Info("resumed the interrupted work", "job", job.ID, "commit", job.Commit)
+		}
+	case gitcommand.RecoverRollback:
+		if err := config.Rollback(ctx, recovery); err != nil {
+			fatal(logger, "cannot roll back the interrupted work", err)
+		}
+		logger.Info("rolled back the interrupted work")
+	default:
+		logger.Warn("interrupted work is left as is, start with -recover resume or -recover rollback",
+			"jobs", len(recovery.Jobs), "unpushed", len(recovery.Unpushed), "partial", len(recovery.Partial),
+			"pending", len(recovery.Pending), "locks", len(recovery.Locks))
+	}
+}
+
+// askRecover asks on the terminal whether to resume or roll back, it ignores the work without a terminal
+func askRecover(recovery *gitcommand.Recovery) string {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return gitcommand.RecoverIgnore
+	}
+
+	fmt.Fprintf(os.Stderr, "The server was interrupted:\n  %s\n", strings.Replace(recovery.String(), "\n", "\n  ", -1))
+	fmt.Fprintf(os.Stderr, "[r]esume by committing and pushing, roll [b]ack by removing the files and commits, or [i]gnore? ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "r", "resume":
+		return gitcommand.RecoverResume
+	case "b", "rollback":
+		return gitcommand.RecoverRollback
+	}
+	return gitcommand.RecoverIgnore
+}
+
+// listFlag is a flag which can be given several times
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,129 @@
+// Sha256 hashes data incrementally, so files larger than memory can be hashed by slices
+(function (global) {
+    var K = [
+        0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+        0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+        0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+        0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+        0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+        0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+        0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+        0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2
+    ];
+
+    function Sha256() {
+        this.h = [0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19];
+        this.w = new Int32Array(64);
+        this.buffer = new Uint8Array(64);
+        this.buffered = 0;
+        this.length = 0;
+    }
+
+    function rotr(x, n) {
+        return (x >>> n) | (x << (32 - n));
+    }
+
+    Sha256.prototype.block = function (data, offset) {
+        var w = this.w, h = this.h, i;
+        for (i = 0; i < 16; i++) {
+            var j = offset + 4 * i;
+            w[i] = (data[j] << 24) | (data[j + 1] << 16) | (data[j + 2] << 8) | data[j + 3];
+        }
+        for (i = 16; i < 64; i++) {
+            var s0 = rotr(w[i - 15], 7) ^ rotr(w[i - 15], 18) ^ (w[i - 15] >>> 3);
+            var s1 = rotr(w[i - 2], 17) ^ rotr(w[i - 2], 19) ^ (w[i - 2] >>> 10);
+            w[i] = (w[i - 16] + s0 + w[i - 7] + s1) | 0;
+        }
+
+        var a = h[0], b = h[1], c = h[2], d = h[3], e = h[4], f = h[5], g = h[6], k = h[7];
+        for (i = 0; i < 64; i++) {
+            var t1 = (k + (rotr(e, 6) ^ rotr(e, 11) ^ rotr(e, 25)) + ((e & f) ^ (~e & g)) + K[i] + w[i]) | 0;
+            var t2 = ((rotr(a, 2) ^ rotr(a, 13) ^ rotr(a, 22)) + ((a & b) ^ (a & c) ^ (b & c))) | 0;
+            k = g;
+            g = f;
+            f = e;
+            e = (d + t1) | 0;
+            d = c;
+            c = b;
+            b = a;
+            a = (t1 + t2) | 0;
+        }
+
+        h[0] = (h[0] + a) | 0;
+        h[1] = (h[1] + b) | 0;
+        h[2] = (h[2] + c) | 0;
+        h[3] = (h[3] + d) | 0;
+        h[4] = (h[4] + e) | 0;
+        h[5] = (h[5] + f) | 0;
+        h[6] = (h[6] + g) | 0;
+        h[7] = (h[7] + k) | 0;
+    };
+
+    // update hashes a Uint8Array
+    Sha256.prototype.update = function (data) {
+        var i = 0;
+        this.length += data.length;
+
+        if (this.buffered > 0) {
+            i = Math.min(64 - this.buffered, data.length);
+            this.buffer.set(data.subarray(0, i), this.buffered);
+            this.buffered += i;
+            if (this.buffered < 64) {
+                return;
+            }
+            this.block(this.buffer, 0);
+            this.buffered = 0;
+        }
+
+        for (; i + 64 <= data.length; i += 64) {
+            this.block(data, i);
+        }
+        this.buffer.set(data.subarray(i), 0);
+        this.buffered = data.length - i;
+    };
+
+    // digest returns the hexadecimal SHA-256 of the data
+    Sha256.prototype.digest = function () {
+        var bits = this.length * 8;
+        var pad = new Uint8Array((this.buffered < 56 ? 64 : 128) - this.buffered);
+        var n = pad.length;
+        pad[0] = 0x80;
+        var high = Math.floor(bits / 0x100000000), low = bits >>> 0;
+        for (var i = 0; i < 4; i++) {
+            pad[n - 8 + i] = (high >>> (24 - 8 * i)) & 0xff;
+            pad[n - 4 + i] = (low >>> (24 - 8 * i)) & 0xff;
+        }
+        this.update(pad);
+
+        return this.h.map(function (x) {
+            return ("0000000" + (x >>> 0).toString(16)).slice(-8);
+        }).join("");
+    };
+
+    // sha256File calls done with an error or the hexadecimal SHA-256 of a file, read by slices of 4 MiB
+    function sha256File(file, done) {
+        var hash = new Sha256(), offset = 0, size = 4 << 20;
+        var reader = new FileReader();
+
+        reader.onload = function () {
+            hash.update(new Uint8Array(reader.result));
+            offset += size;
+            next();
+        };
+        reader.onerror = function () {
+            done(reader.error);
+        };
+
+        function next() {
+            if (offset >= file.size) {
+                done(null, hash.digest());
+                return;
+            }
+            reader.readAsArrayBuffer(file.slice(offset, offset + size));
+        }
+        next();
+    }
+
+    global.Sha256 = Sha256;
+    global.sha256File = sha256File;
+})(this);